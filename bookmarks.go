@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+// Bookmarks are private: nobody but the user who made them can list them,
+// and unlike likes they aren't counted on the chirp or notified to its
+// author.
+
+func (cfg *apiConfig) bookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	chirpID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in bookmark: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if _, err := cfg.getVisibleChirp(r.Context(), chirpID, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	err = cfg.queries.BookmarkChirp(r.Context(), database.BookmarkChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		log.Printf("Error bookmarking chirp: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during bookmark"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unbookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	chirpID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in unbookmark: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	err = cfg.queries.UnbookmarkChirp(r.Context(), database.UnbookmarkChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		log.Printf("Error removing bookmark: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during unbookmark"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getBookmarks lists the caller's bookmarks, most recently bookmarked first.
+func (cfg *apiConfig) getBookmarks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in bookmarks: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Invalid pagination parameters"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	rows, err := cfg.queries.GetUserBookmarks(r.Context(), database.GetUserBookmarksParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt,
+		CursorID:        page.cursorID,
+		PageLimit:       page.queryLimit(),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	// The page is keyed on when each chirp was bookmarked, so the cursor is
+	// built here rather than by page.trim.
+	nextCursor := ""
+	if page.paginated && len(rows) > page.limit {
+		rows = rows[:page.limit]
+		last := rows[len(rows)-1]
+		nextCursor = pagination.EncodeCursor(last.BookmarkedAt, last.Chirp.ID)
+	}
+	cs := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		cs = append(cs, row.Chirp)
+	}
+
+	chirps, err := cfg.buildChirpResponses(r.Context(), cs, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	var jsonResp []byte
+	if page.paginated {
+		jsonResp, err = json.Marshal(chirpPage{Chirps: chirps, NextCursor: nextCursor})
+	} else {
+		jsonResp, err = json.Marshal(chirps)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during response generation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/stream"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// chirpEditWindow is how long after posting a chirp can still be edited.
+// Chirpy Red members can edit any time.
+const chirpEditWindow = 30 * time.Minute
+
+type revisionResponse struct {
+	Revision  int32     `json:"revision"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var errChirpBodyTaken = errors.New("chirp body already exists")
+
+// isChirpBodyConflict reports whether err is the unique index on chirps.body
+// rejecting a body another chirp outside the trash already has.
+func isChirpBodyConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "chirps_body_live_idx"
+}
+
+func (cfg *apiConfig) editChirpByID(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	parsedPath, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in chirp edit: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		log.Printf("Error decoding JSON: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with parsing JSON"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	validated, err := validate_chirp(params.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong during validation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	c, err := cfg.getVisibleChirp(r.Context(), parsedPath, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if userID != c.UserID.UUID {
+		w.WriteHeader(http.StatusForbidden)
+		resp := map[string]string{"error": "Forbidden"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if time.Since(c.CreatedAt) > chirpEditWindow {
+		u, err := cfg.queries.GetUser(r.Context(), userID)
+		if err != nil {
+			log.Printf("Error fetching user for chirp edit: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			resp := map[string]string{"error": "Something went wrong during retrieval"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+		if !u.IsChirpyRed {
+			w.WriteHeader(http.StatusForbidden)
+			resp := map[string]string{"error": "This chirp can no longer be edited"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+	}
+
+	edited, err := cfg.editChirp(r.Context(), c.ID, validated)
+	if errors.Is(err, errChirpBodyTaken) {
+		w.WriteHeader(http.StatusConflict)
+		resp := map[string]string{"error": "A chirp with that body already exists"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if err != nil {
+		log.Printf("Error editing chirp: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during chirp edit"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	chirps, err := cfg.buildChirpResponses(r.Context(), []database.Chirp{edited}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during chirp edit"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	jsonResp, err := json.Marshal(chirps[0])
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during marshalling"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
+
+// editChirp replaces a chirp's body and records the new version. The first
+// edit also records the original, so once a chirp has been edited every
+// version of it is in chirp_revisions. Only users mentioned for the first
+// time are notified.
+func (cfg *apiConfig) editChirp(ctx context.Context, chirpID uuid.UUID, body string) (database.Chirp, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	// Lock the row so concurrent edits get consecutive revision numbers.
+	old, err := qtx.GetChirpForUpdate(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	revisions, err := qtx.CountChirpRevisions(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if revisions == 0 {
+		err = qtx.CreateChirpRevision(ctx, database.CreateChirpRevisionParams{
+			ChirpID:   chirpID,
+			Body:      old.Body,
+			CreatedAt: old.CreatedAt,
+		})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	previous, err := qtx.GetChirpMentions(ctx, []uuid.UUID{chirpID})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	c, err := qtx.UpdateChirpBody(ctx, database.UpdateChirpBodyParams{
+		ID:   chirpID,
+		Body: body,
+	})
+	if err != nil {
+		if isChirpBodyConflict(err) {
+			return database.Chirp{}, errChirpBodyTaken
+		}
+		return database.Chirp{}, err
+	}
+
+	err = qtx.CreateChirpRevision(ctx, database.CreateChirpRevisionParams{
+		ChirpID:   chirpID,
+		Body:      c.Body,
+		CreatedAt: c.UpdatedAt,
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	mentioned, err := linkChirpEntities(ctx, qtx, c)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.Chirp{}, err
+	}
+
+	newlyMentioned := slices.DeleteFunc(mentioned, func(userID uuid.UUID) bool {
+		return slices.ContainsFunc(previous, func(m database.GetChirpMentionsRow) bool {
+			return m.UserID == userID
+		})
+	})
+	cfg.notifyMentioned(c, newlyMentioned)
+	cfg.publishChirp(ctx, stream.EventChirpUpdated, c)
+	return c, nil
+}
+
+// getChirpRevisions lists every version of a chirp, oldest first. A chirp
+// that was never edited has just the one.
+func (cfg *apiConfig) getChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	parsedPath, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	c, err := cfg.getVisibleChirp(r.Context(), parsedPath, cfg.viewerID(r))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			w.WriteHeader(404)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	rs, err := cfg.queries.GetChirpRevisions(r.Context(), c.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	revisions := []revisionResponse{}
+	for _, rev := range rs {
+		revisions = append(revisions, revisionResponse{
+			Revision:  rev.Revision,
+			Body:      rev.Body,
+			CreatedAt: rev.CreatedAt,
+		})
+	}
+	if len(revisions) == 0 {
+		revisions = append(revisions, revisionResponse{
+			Revision:  1,
+			Body:      c.Body,
+			CreatedAt: c.CreatedAt,
+		})
+	}
+
+	jsonResp, err := json.Marshal(revisions)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during response generation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
@@ -1,24 +1,293 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/RobertGolawski/Chirpy/internal/auth"
 	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/hashtag"
+	"github.com/RobertGolawski/Chirpy/internal/mention"
+	"github.com/RobertGolawski/Chirpy/internal/notifications"
+	"github.com/RobertGolawski/Chirpy/internal/stream"
 	"github.com/google/uuid"
 )
 
 type chirpResponse struct {
-	ID        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    string    `json:"user_id"`
+	ID             string               `json:"id"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+	EditedAt       *time.Time           `json:"edited_at,omitempty"`
+	ExpiresAt      *time.Time           `json:"expires_at,omitempty"`
+	Body           string               `json:"body"`
+	UserID         string               `json:"user_id"`
+	InReplyTo      string               `json:"in_reply_to,omitempty"`
+	Visibility     string               `json:"visibility"`
+	ContentWarning string               `json:"content_warning,omitempty"`
+	Sensitive      bool                 `json:"sensitive"`
+	Collapsed      bool                 `json:"collapsed,omitempty"`
+	ReplyCount     int64                `json:"reply_count"`
+	LikeCount      int64                `json:"like_count"`
+	LikedByMe      *bool                `json:"liked_by_me,omitempty"`
+	BookmarkedByMe *bool                `json:"bookmarked_by_me,omitempty"`
+	Pinned         bool                 `json:"pinned,omitempty"`
+	RechirpCount   int64                `json:"rechirp_count"`
+	RechirpedBy    string               `json:"rechirped_by,omitempty"`
+	RechirpedAt    *time.Time           `json:"rechirped_at,omitempty"`
+	QuotedChirp    *quotedChirpResponse `json:"quoted_chirp,omitempty"`
+	Hashtags       []string             `json:"hashtags,omitempty"`
+	Mentions       []mentionResponse    `json:"mentions,omitempty"`
+	Media          []mediaResponse      `json:"media,omitempty"`
+	Poll           *pollResponse        `json:"poll,omitempty"`
+}
+
+// mentionResponse locates an @handle in the chirp body. Start and End are
+// code point offsets, End exclusive.
+type mentionResponse struct {
+	UserID string `json:"user_id"`
+	Handle string `json:"handle"`
+	Start  int32  `json:"start"`
+	End    int32  `json:"end"`
+}
+
+// quotedChirpResponse is the original chirp embedded in a quote. If the
+// original has been deleted only its ID is kept and unavailable is set.
+type quotedChirpResponse struct {
+	ID          string     `json:"id"`
+	Unavailable bool       `json:"unavailable,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	Body        string     `json:"body,omitempty"`
+	UserID      string     `json:"user_id,omitempty"`
+}
+
+// viewerID returns the user behind the request's bearer token, if there is a
+// valid one. Read endpoints use it to personalise responses without requiring
+// a login.
+func (cfg *apiConfig) viewerID(r *http.Request) uuid.NullUUID {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}
+}
+
+// getVisibleChirp fetches a chirp if the viewer is allowed to see it. A chirp
+// hidden from them is reported as sql.ErrNoRows, exactly like one that doesn't
+// exist, so handlers answer 404 either way and never reveal it's there.
+func (cfg *apiConfig) getVisibleChirp(ctx context.Context, id uuid.UUID, viewer uuid.NullUUID) (database.Chirp, error) {
+	return cfg.queries.GetVisibleChirp(ctx, database.GetVisibleChirpParams{
+		ID:       id,
+		ViewerID: viewer,
+	})
+}
+
+// buildChirpResponses turns database rows into API responses, looking up the
+// per-chirp extras for the whole batch at once rather than row by row.
+func (cfg *apiConfig) buildChirpResponses(ctx context.Context, cs []database.Chirp, viewer uuid.NullUUID) ([]chirpResponse, error) {
+	ids := make([]uuid.UUID, 0, len(cs))
+	for _, c := range cs {
+		ids = append(ids, c.ID)
+	}
+
+	replyCounts := map[uuid.UUID]int64{}
+	if len(ids) > 0 {
+		counts, err := cfg.queries.CountReplies(ctx, database.CountRepliesParams{
+			ChirpIds: ids,
+			ViewerID: viewer,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, rc := range counts {
+			replyCounts[rc.InReplyTo.UUID] = rc.ReplyCount
+		}
+	}
+
+	likeCounts := map[uuid.UUID]int64{}
+	if len(ids) > 0 {
+		counts, err := cfg.queries.CountLikes(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, lc := range counts {
+			likeCounts[lc.ChirpID] = lc.LikeCount
+		}
+	}
+
+	rechirpCounts := map[uuid.UUID]int64{}
+	if len(ids) > 0 {
+		counts, err := cfg.queries.CountRechirps(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, rc := range counts {
+			rechirpCounts[rc.ChirpID] = rc.RechirpCount
+		}
+	}
+
+	var quotedIDs []uuid.UUID
+	for _, c := range cs {
+		if c.QuoteOf.Valid {
+			quotedIDs = append(quotedIDs, c.QuoteOf.UUID)
+		}
+	}
+	quoted := map[uuid.UUID]database.Chirp{}
+	if len(quotedIDs) > 0 {
+		qs, err := cfg.queries.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{
+			ChirpIds: quotedIDs,
+			ViewerID: viewer,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range qs {
+			quoted[q.ID] = q
+		}
+	}
+
+	tags := map[uuid.UUID][]string{}
+	if len(ids) > 0 {
+		chirpTags, err := cfg.queries.GetChirpHashtags(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, ct := range chirpTags {
+			tags[ct.ChirpID] = append(tags[ct.ChirpID], ct.Tag)
+		}
+	}
+
+	mentions := map[uuid.UUID][]mentionResponse{}
+	if len(ids) > 0 {
+		chirpMentions, err := cfg.queries.GetChirpMentions(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range chirpMentions {
+			mentions[m.ChirpID] = append(mentions[m.ChirpID], mentionResponse{
+				UserID: m.UserID.String(),
+				Handle: m.Handle.String,
+				Start:  m.StartOffset,
+				End:    m.EndOffset,
+			})
+		}
+	}
+
+	liked := map[uuid.UUID]bool{}
+	if viewer.Valid && len(ids) > 0 {
+		likedIDs, err := cfg.queries.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+			UserID:   viewer.UUID,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range likedIDs {
+			liked[id] = true
+		}
+	}
+
+	bookmarked := map[uuid.UUID]bool{}
+	if viewer.Valid && len(ids) > 0 {
+		bookmarkedIDs, err := cfg.queries.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+			UserID:   viewer.UUID,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range bookmarkedIDs {
+			bookmarked[id] = true
+		}
+	}
+
+	attachments := map[uuid.UUID][]mediaResponse{}
+	if len(ids) > 0 {
+		ms, err := cfg.queries.GetChirpMedia(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range ms {
+			attachments[m.ChirpID] = append(attachments[m.ChirpID], newMediaResponse(m.Medium))
+		}
+	}
+
+	polls, err := cfg.buildPollResponses(ctx, ids, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	showSensitive := false
+	if slices.ContainsFunc(cs, func(c database.Chirp) bool { return c.Sensitive }) {
+		showSensitive, err = cfg.viewerShowsSensitive(ctx, viewer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	chirps := make([]chirpResponse, 0, len(cs))
+	for _, c := range cs {
+		resp := chirpResponse{
+			ID:             c.ID.String(),
+			CreatedAt:      c.CreatedAt,
+			UpdatedAt:      c.UpdatedAt,
+			Body:           c.Body,
+			UserID:         c.UserID.UUID.String(),
+			Visibility:     c.Visibility,
+			ContentWarning: c.ContentWarning.String,
+			Sensitive:      c.Sensitive,
+			Collapsed:      c.Sensitive && !showSensitive,
+			ReplyCount:     replyCounts[c.ID],
+			LikeCount:      likeCounts[c.ID],
+			RechirpCount:   rechirpCounts[c.ID],
+			Hashtags:       tags[c.ID],
+			Mentions:       mentions[c.ID],
+			Media:          attachments[c.ID],
+			Poll:           polls[c.ID],
+			Pinned:         c.PinnedAt.Valid,
+		}
+		if c.InReplyTo.Valid {
+			resp.InReplyTo = c.InReplyTo.UUID.String()
+		}
+		// updated_at only moves when the body is edited.
+		if c.UpdatedAt.After(c.CreatedAt) {
+			editedAt := c.UpdatedAt
+			resp.EditedAt = &editedAt
+		}
+		if c.ExpiresAt.Valid {
+			expiresAt := c.ExpiresAt.Time
+			resp.ExpiresAt = &expiresAt
+		}
+		if c.QuoteOf.Valid {
+			resp.QuotedChirp = &quotedChirpResponse{ID: c.QuoteOf.UUID.String()}
+			if q, ok := quoted[c.QuoteOf.UUID]; ok {
+				resp.QuotedChirp.CreatedAt = &q.CreatedAt
+				resp.QuotedChirp.Body = q.Body
+				resp.QuotedChirp.UserID = q.UserID.UUID.String()
+			} else {
+				resp.QuotedChirp.Unavailable = true
+			}
+		}
+		if viewer.Valid {
+			likedByMe := liked[c.ID]
+			resp.LikedByMe = &likedByMe
+			// Bookmarks are private, so this is only ever about the viewer.
+			bookmarkedByMe := bookmarked[c.ID]
+			resp.BookmarkedByMe = &bookmarkedByMe
+		}
+		chirps = append(chirps, resp)
+	}
+
+	return chirps, nil
 }
 
 func (cfg *apiConfig) get_chirps(w http.ResponseWriter, r *http.Request) {
@@ -32,9 +301,32 @@ func (cfg *apiConfig) get_chirps(w http.ResponseWriter, r *http.Request) {
 	if o == "desc" {
 		order = "desc"
 	}
+	includeSensitive := r.URL.Query().Get("include_sensitive") != "false"
 
 	w.Header().Set("Content-Type", "application/json")
-	cs, err := cfg.queries.GetChirps(r.Context(), order)
+	page, err := parsePageParams(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Invalid pagination parameters"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	// The table is far too big to send whole, so every request gets a page.
+	if !page.paginated {
+		page.paginated = true
+		page.limit = defaultPageLimit
+	}
+
+	viewer := cfg.viewerID(r)
+	cs, err := cfg.queries.GetChirps(r.Context(), database.GetChirpsParams{
+		ViewerID:         viewer,
+		IncludeSensitive: includeSensitive,
+		CursorCreatedAt:  page.cursorCreatedAt,
+		SortOrder:        order,
+		CursorID:         page.cursorID,
+		PageLimit:        page.queryLimit(),
+	})
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		resp := map[string]string{"error": "Something went wrong during retrieval"}
@@ -43,19 +335,17 @@ func (cfg *apiConfig) get_chirps(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var chirps []chirpResponse
-
-	for _, c := range cs {
-		chirps = append(chirps, chirpResponse{
-			ID:        c.ID.String(),
-			CreatedAt: c.CreatedAt,
-			UpdatedAt: c.UpdatedAt,
-			Body:      c.Body,
-			UserID:    c.UserID.UUID.String(),
-		})
+	cs, nextCursor := page.trim(cs)
+	chirps, err := cfg.buildChirpResponses(r.Context(), cs, viewer)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
 	}
 
-	jsonResp, err := json.Marshal(chirps)
+	jsonResp, err := json.Marshal(chirpPage{Chirps: chirps, NextCursor: nextCursor})
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		resp := map[string]string{"error": "Something went wrong during response generation"}
@@ -78,7 +368,8 @@ func (cfg *apiConfig) get_chirp_by_id(w http.ResponseWriter, r *http.Request) {
 		w.Write(jsonResp)
 		return
 	}
-	c, err := cfg.queries.GetChirp(r.Context(), parsedPath)
+	viewer := cfg.viewerID(r)
+	c, err := cfg.getVisibleChirp(r.Context(), parsedPath, viewer)
 	if err != nil {
 		w.WriteHeader(404)
 		resp := map[string]string{"error": "Something went wrong during querying"}
@@ -87,24 +378,34 @@ func (cfg *apiConfig) get_chirp_by_id(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := chirpResponse{
-		ID:        c.ID.String(),
-		CreatedAt: c.CreatedAt,
-		UpdatedAt: c.UpdatedAt,
-		Body:      c.Body,
-		UserID:    c.UserID.UUID.String(),
+	chirps, err := cfg.buildChirpResponses(r.Context(), []database.Chirp{c}, viewer)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
 	}
 
-	jsonResp, _ := json.Marshal(resp)
+	jsonResp, _ := json.Marshal(chirps[0])
 	w.WriteHeader(200)
 	w.Write(jsonResp)
 }
 
 func (cfg *apiConfig) send_chirp(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body    string        `json:"body"`
-		User_id uuid.NullUUID `json:"user_id"`
-		Token   string        `json:"token"`
+		Body           string          `json:"body"`
+		User_id        uuid.NullUUID   `json:"user_id"`
+		Token          string          `json:"token"`
+		InReplyTo      uuid.NullUUID   `json:"in_reply_to"`
+		QuoteOf        uuid.NullUUID   `json:"quote_of"`
+		PublishAt      *time.Time      `json:"publish_at"`
+		MediaIDs       []uuid.UUID     `json:"media_ids"`
+		Poll           *pollParameters `json:"poll"`
+		Visibility     string          `json:"visibility"`
+		ExpiresIn      *int64          `json:"expires_in_seconds"`
+		ContentWarning string          `json:"content_warning"`
+		Sensitive      bool            `json:"sensitive"`
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -138,7 +439,7 @@ func (cfg *apiConfig) send_chirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, err := auth.ValidateJWT(params.Token, cfg.secret)
+	userID, err := auth.ValidateJWT(params.Token, cfg.keys)
 	if err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		log.Printf("error during validation: %v", err)
@@ -156,10 +457,159 @@ func (cfg *apiConfig) send_chirp(w http.ResponseWriter, r *http.Request) {
 	// 	w.Write(jsonResp)
 	// 	return
 	// }
+	if params.InReplyTo.Valid {
+		if _, err := cfg.getVisibleChirp(r.Context(), params.InReplyTo.UUID, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			resp := map[string]string{"error": "Chirp being replied to not found"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+	}
+
+	if params.QuoteOf.Valid {
+		if _, err := cfg.getVisibleChirp(r.Context(), params.QuoteOf.UUID, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			resp := map[string]string{"error": "Quoted chirp not found"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+	}
+
+	if status, msg := cfg.checkMediaIDs(r.Context(), userID, params.MediaIDs); status != 0 {
+		w.WriteHeader(status)
+		resp := map[string]string{"error": msg}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	visibility, ok := parseVisibility(params.Visibility)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "visibility must be public, followers or mentioned"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	warning, sensitive, ok := parseContentWarning(params.ContentWarning, params.Sensitive)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "content_warning can be at most 100 characters"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	expiresIn, err := parseChirpTTL(params.ExpiresIn)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "expires_in_seconds must be between 60 and 604800"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if expiresIn.Valid {
+		u, err := cfg.queries.GetUser(r.Context(), userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			resp := map[string]string{"error": "Something went wrong during querying"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+		if !u.IsChirpyRed {
+			w.WriteHeader(http.StatusForbidden)
+			resp := map[string]string{"error": "Ephemeral chirps are a Chirpy Red feature"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+	}
+
+	poll, msg := checkPoll(params.Poll)
+	if msg != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": msg}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	// A chirp with a publish_at is saved as a scheduled draft and published
+	// later by the scheduler. Drafts have nowhere to keep a poll, and its
+	// closing time would be stale by then anyway.
+	if params.PublishAt != nil {
+		if expiresIn.Valid {
+			w.WriteHeader(http.StatusBadRequest)
+			resp := map[string]string{"error": "Ephemeral chirps can't be scheduled"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+		if poll != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			resp := map[string]string{"error": "Chirps with a poll can't be scheduled"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+
+		publishAt, err := parsePublishAt(params.PublishAt)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			resp := map[string]string{"error": "publish_at must be in the future"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+
+		d, err := cfg.queries.CreateDraft(r.Context(), database.CreateDraftParams{
+			UserID:         userID,
+			Body:           validated,
+			InReplyTo:      params.InReplyTo,
+			QuoteOf:        params.QuoteOf,
+			PublishAt:      publishAt,
+			MediaIds:       nonNilIDs(params.MediaIDs),
+			Visibility:     visibility,
+			ContentWarning: warning,
+			Sensitive:      sensitive,
+		})
+		if err != nil {
+			log.Printf("Error scheduling chirp: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			resp := map[string]string{"error": "Something went wrong during chirp creation"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+
+		jsonResp, err := json.Marshal(newDraftResponse(d))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			resp := map[string]string{"error": "Something went wrong during marshalling"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write(jsonResp)
+		return
+	}
+
 	nullID := uuid.NullUUID{UUID: userID, Valid: true}
-	c, err := cfg.queries.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   validated,
-		UserID: nullID})
+	c, err := cfg.createChirp(r.Context(), database.CreateChirpParams{
+		Body:             validated,
+		UserID:           nullID,
+		InReplyTo:        params.InReplyTo,
+		QuoteOf:          params.QuoteOf,
+		Visibility:       visibility,
+		ExpiresInSeconds: expiresIn,
+		ContentWarning:   warning,
+		Sensitive:        sensitive,
+	}, params.MediaIDs, poll)
 	//uuid.NullUUID{UUID: userID, Valid: true}
 
 	if err != nil {
@@ -170,15 +620,16 @@ func (cfg *apiConfig) send_chirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := chirpResponse{
-		ID:        c.ID.String(),
-		CreatedAt: c.CreatedAt,
-		UpdatedAt: c.UpdatedAt,
-		Body:      c.Body,
-		UserID:    c.UserID.UUID.String(),
+	chirps, err := cfg.buildChirpResponses(r.Context(), []database.Chirp{c}, nullID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during chirp creation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
 	}
 
-	jsonResp, err := json.Marshal(resp)
+	jsonResp, err := json.Marshal(chirps[0])
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		resp := map[string]string{"error": "Something went wrong during marshalling"}
@@ -190,6 +641,157 @@ func (cfg *apiConfig) send_chirp(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonResp)
 }
 
+// createChirp stores a chirp along with its media attachments, its poll if
+// it has one, and the hashtags and @mentions found in its body. Mentions of
+// handles nobody has are left as plain text.
+func (cfg *apiConfig) createChirp(ctx context.Context, params database.CreateChirpParams, mediaIDs []uuid.UUID, poll *pollParameters) (database.Chirp, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	c, mentioned, err := insertChirp(ctx, qtx, params, mediaIDs, poll)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.Chirp{}, err
+	}
+
+	cfg.chirpCreated(ctx, c, mentioned)
+	return c, nil
+}
+
+// insertChirp is the part of createChirp that runs inside the caller's
+// transaction. Whoever commits it must call chirpCreated afterwards.
+func insertChirp(ctx context.Context, qtx *database.Queries, params database.CreateChirpParams, mediaIDs []uuid.UUID, poll *pollParameters) (database.Chirp, []uuid.UUID, error) {
+	c, err := qtx.CreateChirp(ctx, params)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	if err := linkChirpMedia(ctx, qtx, c.ID, mediaIDs); err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	if err := insertPoll(ctx, qtx, c.ID, poll); err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	mentioned, err := linkChirpEntities(ctx, qtx, c)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+	return c, mentioned, nil
+}
+
+// chirpCreated sends the notifications and live events for a newly
+// committed chirp: the parent's author hears about a reply, mentioned users
+// about their mention, and stream subscribers get the chirp itself.
+func (cfg *apiConfig) chirpCreated(ctx context.Context, c database.Chirp, mentioned []uuid.UUID) {
+	if c.InReplyTo.Valid {
+		parent, err := cfg.queries.GetChirp(ctx, c.InReplyTo.UUID)
+		if err == nil && parent.UserID.Valid {
+			cfg.notifier.Notify(notifications.Event{
+				Kind:    notifications.KindReply,
+				UserID:  parent.UserID.UUID,
+				ActorID: c.UserID,
+				ChirpID: uuid.NullUUID{UUID: parent.ID, Valid: true},
+			})
+		}
+	}
+
+	cfg.notifyMentioned(c, mentioned)
+	cfg.publishChirp(ctx, stream.EventChirpCreated, c)
+}
+
+// linkChirpEntities points the chirp's hashtag and mention links at what its
+// body currently contains. Hashtags the chirp already had keep their original
+// timestamp, so editing a chirp doesn't bump it in the trending scores. It
+// returns the users mentioned, without duplicates.
+func linkChirpEntities(ctx context.Context, qtx *database.Queries, c database.Chirp) ([]uuid.UUID, error) {
+	hashtagIDs := []uuid.UUID{}
+	for _, tag := range hashtag.Extract(c.Body) {
+		hashtagID, err := qtx.UpsertHashtag(ctx, tag)
+		if err != nil {
+			return nil, err
+		}
+		err = qtx.AddChirpHashtag(ctx, database.AddChirpHashtagParams{
+			ChirpID:   c.ID,
+			HashtagID: hashtagID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		hashtagIDs = append(hashtagIDs, hashtagID)
+	}
+	err := qtx.DeleteStaleChirpHashtags(ctx, database.DeleteStaleChirpHashtagsParams{
+		ChirpID: c.ID,
+		KeepIds: hashtagIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := qtx.DeleteChirpMentions(ctx, c.ID); err != nil {
+		return nil, err
+	}
+
+	var mentioned []uuid.UUID
+	found := mention.Extract(c.Body)
+	if len(found) == 0 {
+		return mentioned, nil
+	}
+
+	handles := make([]string, 0, len(found))
+	for _, m := range found {
+		handles = append(handles, mention.Normalize(m.Handle))
+	}
+	users, err := qtx.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := map[string]uuid.UUID{}
+	for _, u := range users {
+		userIDs[mention.Normalize(u.Handle.String)] = u.ID
+	}
+
+	for _, m := range found {
+		userID, ok := userIDs[mention.Normalize(m.Handle)]
+		if !ok {
+			continue
+		}
+		err = qtx.AddChirpMention(ctx, database.AddChirpMentionParams{
+			ChirpID:     c.ID,
+			UserID:      userID,
+			StartOffset: int32(m.Start),
+			EndOffset:   int32(m.End),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(mentioned, userID) {
+			mentioned = append(mentioned, userID)
+		}
+	}
+
+	return mentioned, nil
+}
+
+func (cfg *apiConfig) notifyMentioned(c database.Chirp, mentioned []uuid.UUID) {
+	for _, userID := range mentioned {
+		cfg.notifier.Notify(notifications.Event{
+			Kind:    notifications.KindMention,
+			UserID:  userID,
+			ActorID: c.UserID,
+			ChirpID: uuid.NullUUID{UUID: c.ID, Valid: true},
+		})
+	}
+}
+
 func validate_chirp(s string) (string, error) {
 
 	if len(s) > 140 {
@@ -233,7 +835,7 @@ func (cfg *apiConfig) deleteChirpByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, err := auth.ValidateJWT(bearerToken, cfg.secret)
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
 	if err != nil {
 		log.Printf("Error with validation of the JWT in user update: %v", err)
 		w.WriteHeader(http.StatusUnauthorized)
@@ -243,7 +845,7 @@ func (cfg *apiConfig) deleteChirpByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	c, err := cfg.queries.GetChirp(r.Context(), parsedPath)
+	c, err := cfg.getVisibleChirp(r.Context(), parsedPath, uuid.NullUUID{UUID: userID, Valid: true})
 	if err != nil {
 		w.WriteHeader(404)
 		resp := map[string]string{"error": "Something went wrong during querying"}
@@ -260,10 +862,10 @@ func (cfg *apiConfig) deleteChirpByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = cfg.queries.DeleteChirp(r.Context(), parsedPath)
+	err = cfg.deleteChirp(r.Context(), c)
 	if err != nil {
-		log.Fatalf("Error during database operation to delete chirp: %v", err)
-		w.WriteHeader(http.StatusForbidden)
+		log.Printf("Error during database operation to delete chirp: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
 		resp := map[string]string{"error": "Error with deleting chirp"}
 		jsonResp, _ := json.Marshal(resp)
 		w.Write(jsonResp)
@@ -273,12 +875,62 @@ func (cfg *apiConfig) deleteChirpByID(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// deleteChirp moves a chirp to its author's trash, hiding it from every read
+// path. Likes, rechirps and hashtag links stay until the trash is purged, so
+// a restored chirp comes back intact.
+func (cfg *apiConfig) deleteChirp(ctx context.Context, c database.Chirp) error {
+	audience, err := cfg.chirpAudience(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	if err := removeChirp(ctx, qtx, c); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	cfg.publishChirpDeleted(c, audience)
+	return nil
+}
+
+// removeChirp is the part of deleteChirp that runs inside the caller's
+// transaction. If anything replies to the chirp, whoever can see it, a
+// tombstone is left behind so the rest of the thread can still be walked.
+func removeChirp(ctx context.Context, qtx *database.Queries, c database.Chirp) error {
+	hasReplies, err := qtx.HasReplies(ctx, uuid.NullUUID{UUID: c.ID, Valid: true})
+	if err != nil {
+		return err
+	}
+	if hasReplies {
+		err = qtx.CreateChirpTombstone(ctx, database.CreateChirpTombstoneParams{
+			ID:        c.ID,
+			CreatedAt: c.CreatedAt,
+			InReplyTo: c.InReplyTo,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return qtx.DeleteChirp(ctx, c.ID)
+}
+
 func (cfg *apiConfig) get_chirps_for_user(w http.ResponseWriter, r *http.Request) {
 	order := "asc"
 	o := r.URL.Query().Get("sort")
 	if o == "desc" {
 		order = "desc"
 	}
+	includeSensitive := r.URL.Query().Get("include_sensitive") != "false"
 	s := r.URL.Query().Get("author_id")
 	parsedPath, err := uuid.Parse(s)
 	if err != nil {
@@ -292,7 +944,30 @@ func (cfg *apiConfig) get_chirps_for_user(w http.ResponseWriter, r *http.Request
 	nullID := uuid.NullUUID{UUID: parsedPath, Valid: true}
 
 	w.Header().Set("Content-Type", "application/json")
-	cs, err := cfg.queries.GetUserChirps(r.Context(), database.GetUserChirpsParams{UserID: nullID, SortOrder: order})
+	page, err := parsePageParams(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Invalid pagination parameters"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	// The table is far too big to send whole, so every request gets a page.
+	if !page.paginated {
+		page.paginated = true
+		page.limit = defaultPageLimit
+	}
+
+	viewer := cfg.viewerID(r)
+	rows, err := cfg.queries.GetUserChirps(r.Context(), database.GetUserChirpsParams{
+		UserID:           nullID,
+		ViewerID:         viewer,
+		IncludeSensitive: includeSensitive,
+		CursorCreatedAt:  page.cursorCreatedAt,
+		SortOrder:        order,
+		CursorID:         page.cursorID,
+		PageLimit:        page.queryLimit(),
+	})
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		resp := map[string]string{"error": "Something went wrong during retrieval"}
@@ -301,19 +976,31 @@ func (cfg *apiConfig) get_chirps_for_user(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var chirps []chirpResponse
+	entries, nextCursor := page.trimFeed(userFeedEntries(rows))
+	pinned, err := cfg.queries.GetPinnedChirps(r.Context(), database.GetPinnedChirpsParams{
+		UserID:           nullID,
+		ViewerID:         viewer,
+		IncludeSensitive: includeSensitive,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	entries = withPinnedFirst(entries, pinned, !page.cursorID.Valid)
 
-	for _, c := range cs {
-		chirps = append(chirps, chirpResponse{
-			ID:        c.ID.String(),
-			CreatedAt: c.CreatedAt,
-			UpdatedAt: c.UpdatedAt,
-			Body:      c.Body,
-			UserID:    c.UserID.UUID.String(),
-		})
+	chirps, err := cfg.buildFeedResponses(r.Context(), entries, viewer)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
 	}
 
-	jsonResp, err := json.Marshal(chirps)
+	jsonResp, err := json.Marshal(chirpPage{Chirps: chirps, NextCursor: nextCursor})
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		resp := map[string]string{"error": "Something went wrong during response generation"}
@@ -0,0 +1,711 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Drafts live in their own table until they're published, so none of the
+// chirp queries ever see them. A draft with publish_at set is a scheduled
+// chirp; the scheduler job publishes it once it's due.
+
+const scheduledChirpInterval = 10 * time.Second
+
+var (
+	errDraftReplyGone = errors.New("chirp being replied to not found")
+	errDraftQuoteGone = errors.New("quoted chirp not found")
+)
+
+type draftResponse struct {
+	ID             string     `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	Body           string     `json:"body"`
+	InReplyTo      string     `json:"in_reply_to,omitempty"`
+	QuoteOf        string     `json:"quote_of,omitempty"`
+	PublishAt      *time.Time `json:"publish_at,omitempty"`
+	MediaIDs       []string   `json:"media_ids,omitempty"`
+	Visibility     string     `json:"visibility"`
+	ContentWarning string     `json:"content_warning,omitempty"`
+	Sensitive      bool       `json:"sensitive"`
+	LastError      string     `json:"last_error,omitempty"`
+}
+
+type draftParameters struct {
+	Body           string        `json:"body"`
+	InReplyTo      uuid.NullUUID `json:"in_reply_to"`
+	QuoteOf        uuid.NullUUID `json:"quote_of"`
+	PublishAt      *time.Time    `json:"publish_at"`
+	MediaIDs       []uuid.UUID   `json:"media_ids"`
+	Visibility     string        `json:"visibility"`
+	ContentWarning string        `json:"content_warning"`
+	Sensitive      bool          `json:"sensitive"`
+}
+
+func newDraftResponse(d database.ChirpDraft) draftResponse {
+	resp := draftResponse{
+		ID:             d.ID.String(),
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+		Body:           d.Body,
+		Visibility:     d.Visibility,
+		ContentWarning: d.ContentWarning.String,
+		Sensitive:      d.Sensitive,
+		LastError:      d.LastError.String,
+	}
+	if d.InReplyTo.Valid {
+		resp.InReplyTo = d.InReplyTo.UUID.String()
+	}
+	if d.QuoteOf.Valid {
+		resp.QuoteOf = d.QuoteOf.UUID.String()
+	}
+	if d.PublishAt.Valid {
+		publishAt := d.PublishAt.Time
+		resp.PublishAt = &publishAt
+	}
+	for _, id := range d.MediaIds {
+		resp.MediaIDs = append(resp.MediaIDs, id.String())
+	}
+	return resp
+}
+
+// nonNilIDs keeps an empty media list from being written as NULL, which
+// media_ids doesn't allow.
+func nonNilIDs(ids []uuid.UUID) []uuid.UUID {
+	if ids == nil {
+		return []uuid.UUID{}
+	}
+	return ids
+}
+
+// parsePublishAt turns an optional publish_at into the column value. Times
+// are stored in UTC and compared against the scheduler's UTC clock, so the
+// database's own timezone doesn't matter.
+func parsePublishAt(publishAt *time.Time) (sql.NullTime, error) {
+	if publishAt == nil {
+		return sql.NullTime{}, nil
+	}
+	if !publishAt.After(time.Now()) {
+		return sql.NullTime{}, errors.New("publish_at must be in the future")
+	}
+	return sql.NullTime{Time: publishAt.UTC(), Valid: true}, nil
+}
+
+// checkDraft applies the same rules send_chirp does to a draft about to be
+// saved, filling in the default visibility if it's missing and trimming the
+// content warning. It returns the cleaned body, or the status and message to
+// fail with.
+func (cfg *apiConfig) checkDraft(ctx context.Context, userID uuid.UUID, params *draftParameters) (string, sql.NullTime, int, string) {
+	validated, err := validate_chirp(params.Body)
+	if err != nil {
+		return "", sql.NullTime{}, http.StatusBadRequest, "Something went wrong during validation"
+	}
+
+	visibility, ok := parseVisibility(params.Visibility)
+	if !ok {
+		return "", sql.NullTime{}, http.StatusBadRequest, "visibility must be public, followers or mentioned"
+	}
+	params.Visibility = visibility
+
+	warning, sensitive, ok := parseContentWarning(params.ContentWarning, params.Sensitive)
+	if !ok {
+		return "", sql.NullTime{}, http.StatusBadRequest, "content_warning can be at most 100 characters"
+	}
+	params.ContentWarning = warning.String
+	params.Sensitive = sensitive
+
+	publishAt, err := parsePublishAt(params.PublishAt)
+	if err != nil {
+		return "", sql.NullTime{}, http.StatusBadRequest, "publish_at must be in the future"
+	}
+
+	if params.InReplyTo.Valid {
+		if _, err := cfg.getVisibleChirp(ctx, params.InReplyTo.UUID, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+			return "", sql.NullTime{}, http.StatusNotFound, "Chirp being replied to not found"
+		}
+	}
+	if params.QuoteOf.Valid {
+		if _, err := cfg.getVisibleChirp(ctx, params.QuoteOf.UUID, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+			return "", sql.NullTime{}, http.StatusNotFound, "Quoted chirp not found"
+		}
+	}
+	if status, msg := cfg.checkMediaIDs(ctx, userID, params.MediaIDs); status != 0 {
+		return "", sql.NullTime{}, status, msg
+	}
+
+	return validated, publishAt, 0, ""
+}
+
+func (cfg *apiConfig) createDraft(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in drafts: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := draftParameters{}
+	if err := decoder.Decode(&params); err != nil {
+		log.Printf("Error decoding JSON: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with parsing JSON"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	body, publishAt, status, msg := cfg.checkDraft(r.Context(), userID, &params)
+	if status != 0 {
+		w.WriteHeader(status)
+		resp := map[string]string{"error": msg}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	d, err := cfg.queries.CreateDraft(r.Context(), database.CreateDraftParams{
+		UserID:         userID,
+		Body:           body,
+		InReplyTo:      params.InReplyTo,
+		QuoteOf:        params.QuoteOf,
+		PublishAt:      publishAt,
+		MediaIds:       nonNilIDs(params.MediaIDs),
+		Visibility:     params.Visibility,
+		ContentWarning: sql.NullString{String: params.ContentWarning, Valid: params.ContentWarning != ""},
+		Sensitive:      params.Sensitive,
+	})
+	if err != nil {
+		log.Printf("Error creating draft: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during draft creation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	jsonResp, err := json.Marshal(newDraftResponse(d))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during marshalling"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonResp)
+}
+
+func (cfg *apiConfig) getDrafts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in drafts: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	ds, err := cfg.queries.GetUserDrafts(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching drafts: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	drafts := []draftResponse{}
+	for _, d := range ds {
+		drafts = append(drafts, newDraftResponse(d))
+	}
+
+	jsonResp, err := json.Marshal(drafts)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during response generation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
+
+func (cfg *apiConfig) getDraft(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	draftID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in drafts: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	d, err := cfg.queries.GetDraft(r.Context(), database.GetDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	jsonResp, err := json.Marshal(newDraftResponse(d))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during response generation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
+
+// updateDraft replaces a draft's contents. Leaving publish_at out turns a
+// scheduled chirp back into a plain draft.
+func (cfg *apiConfig) updateDraft(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	draftID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in drafts: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := draftParameters{}
+	if err := decoder.Decode(&params); err != nil {
+		log.Printf("Error decoding JSON: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with parsing JSON"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	body, publishAt, status, msg := cfg.checkDraft(r.Context(), userID, &params)
+	if status != 0 {
+		w.WriteHeader(status)
+		resp := map[string]string{"error": msg}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	d, err := cfg.queries.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		ID:             draftID,
+		UserID:         userID,
+		Body:           body,
+		InReplyTo:      params.InReplyTo,
+		QuoteOf:        params.QuoteOf,
+		PublishAt:      publishAt,
+		MediaIds:       nonNilIDs(params.MediaIDs),
+		Visibility:     params.Visibility,
+		ContentWarning: sql.NullString{String: params.ContentWarning, Valid: params.ContentWarning != ""},
+		Sensitive:      params.Sensitive,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Draft not found"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating draft: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during draft update"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	jsonResp, err := json.Marshal(newDraftResponse(d))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during marshalling"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
+
+func (cfg *apiConfig) deleteDraft(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	draftID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in drafts: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	n, err := cfg.queries.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		log.Printf("Error deleting draft: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during draft deletion"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if n == 0 {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Draft not found"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// publishDraftByID publishes a draft right away, whether or not it was
+// scheduled.
+func (cfg *apiConfig) publishDraftByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	draftID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in drafts: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	c, err := cfg.publishDraft(r.Context(), func(qtx *database.Queries) (database.ChirpDraft, error) {
+		return qtx.LockDraft(r.Context(), database.LockDraftParams{
+			ID:     draftID,
+			UserID: userID,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Draft not found"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if isChirpBodyConflict(err) {
+		w.WriteHeader(http.StatusConflict)
+		resp := map[string]string{"error": "A chirp with that body already exists"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if errors.Is(err, errDraftReplyGone) || errors.Is(err, errDraftQuoteGone) {
+		w.WriteHeader(http.StatusNotFound)
+		resp := map[string]string{"error": draftPublishError(err)}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if err != nil {
+		log.Printf("Error publishing draft: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during chirp creation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	chirps, err := cfg.buildChirpResponses(r.Context(), []database.Chirp{c}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during chirp creation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	jsonResp, err := json.Marshal(chirps[0])
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during marshalling"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonResp)
+}
+
+// publishDraft turns a draft into a chirp. lock fetches the draft with a row
+// lock inside the transaction; the draft is deleted in that same transaction,
+// so however many instances race for it, only one chirp is ever created.
+func (cfg *apiConfig) publishDraft(ctx context.Context, lock func(qtx *database.Queries) (database.ChirpDraft, error)) (database.Chirp, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	d, err := lock(qtx)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	// What the draft replies to or quotes may have been deleted, or hidden
+	// from its author, since the draft was saved.
+	author := uuid.NullUUID{UUID: d.UserID, Valid: true}
+	if d.InReplyTo.Valid {
+		if _, err := qtx.GetVisibleChirp(ctx, database.GetVisibleChirpParams{ID: d.InReplyTo.UUID, ViewerID: author}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return database.Chirp{}, errDraftReplyGone
+			}
+			return database.Chirp{}, err
+		}
+	}
+	if d.QuoteOf.Valid {
+		if _, err := qtx.GetVisibleChirp(ctx, database.GetVisibleChirpParams{ID: d.QuoteOf.UUID, ViewerID: author}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return database.Chirp{}, errDraftQuoteGone
+			}
+			return database.Chirp{}, err
+		}
+	}
+
+	c, mentioned, err := insertChirp(ctx, qtx, database.CreateChirpParams{
+		Body:           d.Body,
+		UserID:         uuid.NullUUID{UUID: d.UserID, Valid: true},
+		InReplyTo:      d.InReplyTo,
+		QuoteOf:        d.QuoteOf,
+		Visibility:     d.Visibility,
+		ContentWarning: d.ContentWarning,
+		Sensitive:      d.Sensitive,
+	}, d.MediaIds, nil)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	_, err = qtx.DeleteDraft(ctx, database.DeleteDraftParams{
+		ID:     d.ID,
+		UserID: d.UserID,
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.Chirp{}, err
+	}
+
+	cfg.chirpCreated(ctx, c, mentioned)
+	return c, nil
+}
+
+// publishScheduledChirps publishes every scheduled chirp that's due, one per
+// transaction. SKIP LOCKED lets several instances share the work without
+// waiting on each other or picking the same draft twice. A draft that fails
+// for any reason other than the database being briefly unavailable is
+// turned back into a plain draft with the reason recorded, rather than being
+// retried forever and holding up every draft due after it.
+func (cfg *apiConfig) publishScheduledChirps(ctx context.Context) error {
+	for {
+		var claimed database.ChirpDraft
+		_, err := cfg.publishDraft(ctx, func(qtx *database.Queries) (database.ChirpDraft, error) {
+			d, err := qtx.ClaimDueDraft(ctx, time.Now().UTC())
+			claimed = d
+			return d, err
+		})
+		if claimed.ID == uuid.Nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+		if err == nil {
+			continue
+		}
+		if isTransientDBError(err) {
+			return err
+		}
+
+		log.Printf("Error publishing scheduled draft %v: %v", claimed.ID, err)
+		err = cfg.queries.UnscheduleDraft(ctx, database.UnscheduleDraftParams{
+			ID:        claimed.ID,
+			LastError: sql.NullString{String: draftPublishError(err), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// draftPublishError is the reason recorded on a scheduled draft that
+// couldn't be published, for its author to read.
+func draftPublishError(err error) string {
+	switch {
+	case isChirpBodyConflict(err):
+		return "A chirp with that body already exists"
+	case errors.Is(err, errDraftReplyGone):
+		return "Chirp being replied to not found"
+	case errors.Is(err, errDraftQuoteGone):
+		return "Quoted chirp not found"
+	default:
+		return "Something went wrong during chirp creation"
+	}
+}
+
+// isTransientDBError reports whether err is the kind of failure, like a
+// dropped connection or a deadlock, that's worth trying again later.
+func isTransientDBError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		// Connection exceptions, serialization failures and deadlocks,
+		// insufficient resources, and the server shutting down.
+		case "08", "40", "53", "57":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ephemeral chirps are a Chirpy Red feature. Every read query stops
+// returning a chirp the moment its expires_at passes; the reaper catches up
+// afterwards and removes it for good, going through the same steps as a
+// delete so threads, counters and stream subscribers see the usual
+// chirp.deleted. Unlike a delete, nothing is left in the trash.
+
+const (
+	minChirpTTL           = time.Minute
+	maxChirpTTL           = 7 * 24 * time.Hour
+	ephemeralReapInterval = 30 * time.Second
+)
+
+// parseChirpTTL checks expires_in_seconds from send_chirp.
+func parseChirpTTL(seconds *int64) (sql.NullFloat64, error) {
+	if seconds == nil {
+		return sql.NullFloat64{}, nil
+	}
+	ttl := time.Duration(*seconds) * time.Second
+	if ttl < minChirpTTL || ttl > maxChirpTTL {
+		return sql.NullFloat64{}, errors.New("expires_in_seconds out of range")
+	}
+	return sql.NullFloat64{Float64: ttl.Seconds(), Valid: true}, nil
+}
+
+// reapExpiredChirps removes every expired chirp, one per transaction, so
+// several instances can share the work through SKIP LOCKED.
+func (cfg *apiConfig) reapExpiredChirps(ctx context.Context) error {
+	for {
+		err := cfg.reapExpiredChirp(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (cfg *apiConfig) reapExpiredChirp(ctx context.Context) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	c, err := qtx.ClaimExpiredChirp(ctx)
+	if err != nil {
+		return err
+	}
+
+	// A chirp its author already trashed has had its tombstone and
+	// chirp.deleted event; it only needs removing.
+	live := !c.DeletedAt.Valid
+	var audience map[uuid.UUID]bool
+	if live {
+		audience, err = cfg.chirpAudience(ctx, c)
+		if err != nil {
+			return err
+		}
+		if err := removeChirp(ctx, qtx, c); err != nil {
+			return err
+		}
+	}
+	if err := qtx.DeleteChirpPermanently(ctx, c.ID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if live {
+		cfg.publishChirpDeleted(c, audience)
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/notifications"
+	"github.com/google/uuid"
+)
+
+type followResponse struct {
+	ID         string    `json:"id"`
+	Handle     string    `json:"handle,omitempty"`
+	IsRed      bool      `json:"is_chirpy_red"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+func (cfg *apiConfig) followUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	followeeID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in follow: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if userID == followeeID {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "You cannot follow yourself"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if _, err := cfg.queries.GetUser(r.Context(), followeeID); err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "User not found"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	err = cfg.queries.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		log.Printf("Error following user: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during follow"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	cfg.notifier.Notify(notifications.Event{
+		Kind:    notifications.KindFollow,
+		UserID:  followeeID,
+		ActorID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unfollowUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	followeeID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in unfollow: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	err = cfg.queries.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		log.Printf("Error unfollowing user: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during unfollow"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getFollowers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	userID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	fs, err := cfg.queries.GetFollowers(r.Context(), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	followers := []followResponse{}
+	for _, f := range fs {
+		followers = append(followers, followResponse{
+			ID:         f.ID.String(),
+			Handle:     f.Handle.String,
+			IsRed:      f.IsChirpyRed,
+			FollowedAt: f.FollowedAt,
+		})
+	}
+
+	jsonResp, err := json.Marshal(followers)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during response generation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
+
+func (cfg *apiConfig) getFollowing(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	userID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	fs, err := cfg.queries.GetFollowing(r.Context(), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	following := []followResponse{}
+	for _, f := range fs {
+		following = append(following, followResponse{
+			ID:         f.ID.String(),
+			Handle:     f.Handle.String,
+			IsRed:      f.IsChirpyRed,
+			FollowedAt: f.FollowedAt,
+		})
+	}
+
+	jsonResp, err := json.Marshal(following)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during response generation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
+
+func (cfg *apiConfig) getTimeline(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	order := "desc"
+	o := r.URL.Query().Get("sort")
+	if o == "asc" {
+		order = "asc"
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in timeline: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Invalid pagination parameters"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	rows, err := cfg.queries.GetTimeline(r.Context(), database.GetTimelineParams{
+		FollowerID:      userID,
+		CursorCreatedAt: page.cursorCreatedAt,
+		SortOrder:       order,
+		CursorID:        page.cursorID,
+		PageLimit:       page.queryLimit(),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	entries, nextCursor := page.trimFeed(timelineEntries(rows))
+	chirps, err := cfg.buildFeedResponses(r.Context(), entries, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	var jsonResp []byte
+	if page.paginated {
+		jsonResp, err = json.Marshal(chirpPage{Chirps: chirps, NextCursor: nextCursor})
+	} else {
+		jsonResp, err = json.Marshal(chirps)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during response generation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
@@ -2,11 +2,13 @@ module github.com/RobertGolawski/Chirpy
 
 go 1.23.1
 
-require golang.org/x/crypto v0.29.0
-
 require (
-	github.com/golang-jwt/jwt/v5 v5.2.1 // indirect
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/golang-jwt/jwt/v5 v5.2.1
+	github.com/google/uuid v1.6.0
+	github.com/gorilla/websocket v1.5.3
+	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.10.9
+	golang.org/x/crypto v0.29.0
+	golang.org/x/image v0.18.0
+	golang.org/x/text v0.20.0
 )
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/hashtag"
+)
+
+const (
+	trendingWindow   = 24 * time.Hour
+	trendingHalfLife = 6 * time.Hour
+	trendingLimit    = 10
+	maxTrendingLimit = 50
+)
+
+type trendingHashtagResponse struct {
+	Tag   string  `json:"tag"`
+	Uses  int64   `json:"uses"`
+	Score float64 `json:"score"`
+}
+
+func (cfg *apiConfig) getHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	tag := hashtag.Normalize(r.PathValue("tag"))
+	order := "desc"
+	o := r.URL.Query().Get("sort")
+	if o == "asc" {
+		order = "asc"
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Invalid pagination parameters"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if !page.paginated {
+		page.paginated = true
+		page.limit = defaultPageLimit
+	}
+
+	viewer := cfg.viewerID(r)
+	cs, err := cfg.queries.GetHashtagChirps(r.Context(), database.GetHashtagChirpsParams{
+		ViewerID:        viewer,
+		Tag:             tag,
+		CursorCreatedAt: page.cursorCreatedAt,
+		SortOrder:       order,
+		CursorID:        page.cursorID,
+		PageLimit:       page.queryLimit(),
+	})
+	if err != nil {
+		log.Printf("Error fetching hashtag chirps: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	cs, nextCursor := page.trim(cs)
+	chirps, err := cfg.buildChirpResponses(r.Context(), cs, viewer)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	jsonResp, err := json.Marshal(chirpPage{Chirps: chirps, NextCursor: nextCursor})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during response generation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
+
+// getTrendingHashtags scores each tag used in the last trendingWindow by
+// summing its uses, with every use decaying by half each trendingHalfLife.
+// A burst of recent uses therefore beats a steady trickle from hours ago.
+func (cfg *apiConfig) getTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	limit := trendingLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			resp := map[string]string{"error": "Invalid limit"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+		limit = min(parsed, maxTrendingLimit)
+	}
+
+	ts, err := cfg.queries.GetTrendingHashtags(r.Context(), database.GetTrendingHashtagsParams{
+		HalfLifeSeconds: trendingHalfLife.Seconds(),
+		WindowSeconds:   trendingWindow.Seconds(),
+		MaxTags:         int32(limit),
+	})
+	if err != nil {
+		log.Printf("Error fetching trending hashtags: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	trending := []trendingHashtagResponse{}
+	for _, t := range ts {
+		trending = append(trending, trendingHashtagResponse{
+			Tag:   t.Tag,
+			Uses:  t.Uses,
+			Score: t.Score,
+		})
+	}
+
+	jsonResp, err := json.Marshal(trending)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during response generation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
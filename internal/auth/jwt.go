@@ -4,21 +4,31 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
+// MakeJWT signs an access token for userID with the key set's current
+// signing key, naming the key in the kid header.
+func MakeJWT(userID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
+	now := time.Now()
+	key, err := keys.signer(now)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(signingMethod(key.Algorithm),
 		&jwt.RegisteredClaims{
 			Issuer:    "Chirpy",
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
 			Subject:   userID.String(),
 		})
-	signed, err := token.SignedString([]byte(tokenSecret))
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.Private)
 	if err != nil {
 		log.Println("There has been an error with signing the token")
 		return "", err
@@ -27,32 +37,66 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 	return signed, nil
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+func signingMethod(alg string) jwt.SigningMethod {
+	if alg == AlgRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+func ValidateJWT(tokenString string, keys *KeySet) (uuid.UUID, error) {
+	id, _, err := ValidateJWTWithExpiry(tokenString, keys)
+	return id, err
+}
+
+// ValidateJWTWithExpiry is ValidateJWT for callers that hold on to a token,
+// like websocket connections, and need to know when it stops being valid.
+// The token must be signed with a key from keys that hasn't retired, using
+// that key's own algorithm.
+func ValidateJWTWithExpiry(tokenString string, keys *KeySet) (uuid.UUID, time.Time, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(tokenSecret), nil
-	})
+		kid, _ := t.Header["kid"].(string)
+		key, ok := keys.verifier(kid, time.Now())
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		if t.Method.Alg() != key.Algorithm {
+			return nil, errors.New("token algorithm doesn't match its key")
+		}
+		return key.Private.Public(), nil
+	}, jwt.WithValidMethods([]string{AlgEdDSA, AlgRS256}))
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, time.Time{}, err
 	}
 
 	id, err := token.Claims.GetSubject()
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, time.Time{}, err
 	}
 	parsedID, err := uuid.Parse(id)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, time.Time{}, err
 	}
 
-	return parsedID, nil
+	expiresAt, err := token.Claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return uuid.Nil, time.Time{}, errors.New("token has no expiry")
+	}
+
+	return parsedID, expiresAt.Time, nil
 }
 
+// GetBearerToken returns the token from an "Authorization: Bearer <token>"
+// header. Anything else, including an empty token, is an error.
 func GetBearerToken(headers http.Header) (string, error) {
 	token := headers.Get("Authorization")
 	if token == "" {
 		return "", errors.New("no bearer token")
 	}
-	t := token[7:]
+	t, ok := strings.CutPrefix(token, "Bearer ")
+	if !ok || t == "" {
+		return "", errors.New("malformed bearer token")
+	}
 
 	return t, nil
 }
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// Algorithms a SigningKey can use, named as they appear in a JWT's alg
+// header.
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+const rsaKeyBits = 2048
+
+var errNoSigningKey = errors.New("no signing key available")
+
+// SigningKey is one of the keys access tokens are signed with. A key is
+// published and accepted from the moment it's loaded, but only signs tokens
+// from NotBefore on, so everyone verifying tokens has it before it's used.
+// Once it has been replaced RetiresAt is set, and after that tokens signed
+// with it are rejected.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	NotBefore time.Time
+	RetiresAt time.Time
+}
+
+func (k SigningKey) retired(now time.Time) bool {
+	return !k.RetiresAt.IsZero() && !now.Before(k.RetiresAt)
+}
+
+// GenerateSigningKey makes a new key with a random ID. alg is AlgEdDSA or
+// AlgRS256.
+func GenerateSigningKey(alg string) (SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return SigningKey{}, err
+	}
+	return SigningKey{ID: hex.EncodeToString(id), Algorithm: alg, Private: private}, nil
+}
+
+// MarshalPrivateKey encodes a key's private half as PKCS #8 for storage.
+func MarshalPrivateKey(k SigningKey) ([]byte, error) {
+	return x509.MarshalPKCS8PrivateKey(k.Private)
+}
+
+// ParsePrivateKey decodes a private key stored by MarshalPrivateKey and checks
+// it matches alg.
+func ParsePrivateKey(alg string, der []byte) (crypto.Signer, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		if alg == AlgEdDSA {
+			return key, nil
+		}
+	case *rsa.PrivateKey:
+		if alg == AlgRS256 {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("stored key doesn't match algorithm %q", alg)
+}
+
+// KeySet holds the keys currently in use. It's safe for concurrent use, and
+// Replace swaps the whole set at once when the keys are reloaded.
+type KeySet struct {
+	mu   sync.RWMutex
+	keys []SigningKey
+}
+
+func NewKeySet(keys ...SigningKey) *KeySet {
+	return &KeySet{keys: keys}
+}
+
+func (ks *KeySet) Replace(keys []SigningKey) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = keys
+}
+
+// signer returns the key new tokens are signed with: the one that became
+// usable most recently.
+func (ks *KeySet) signer(now time.Time) (SigningKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	var best SigningKey
+	found := false
+	for _, k := range ks.keys {
+		if k.retired(now) || now.Before(k.NotBefore) {
+			continue
+		}
+		if !found || k.NotBefore.After(best.NotBefore) {
+			best, found = k, true
+		}
+	}
+	if !found {
+		return SigningKey{}, errNoSigningKey
+	}
+	return best, nil
+}
+
+// verifier returns the public key for kid, as long as it hasn't retired.
+func (ks *KeySet) verifier(kid string, now time.Time) (SigningKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	for _, k := range ks.keys {
+		if k.ID == kid && !k.retired(now) {
+			return k, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+// JWK is the public half of a SigningKey in RFC 7517 form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public keys of every key that hasn't retired, including
+// ones that aren't signing yet, so verifiers can fetch them in advance.
+func (ks *KeySet) JWKS(now time.Time) JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	set := JWKS{Keys: []JWK{}}
+	for _, k := range ks.keys {
+		if k.retired(now) {
+			continue
+		}
+		jwk := JWK{Use: "sig", Kid: k.ID, Alg: k.Algorithm}
+		switch pub := k.Private.Public().(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// mustKeySet returns a key set with one fresh key of the given algorithm
+// that's already signing.
+func mustKeySet(alg string) *KeySet {
+	k, err := GenerateSigningKey(alg)
+	if err != nil {
+		panic(err)
+	}
+	return NewKeySet(k)
+}
+
+func TestRS256RoundTrip(t *testing.T) {
+	keys := mustKeySet(AlgRS256)
+	id := uuid.New()
+
+	tokenString, err := MakeJWT(id, keys, time.Minute)
+	if err != nil {
+		t.Fatalf("Error happened during making: %v", err)
+	}
+	idValidated, err := ValidateJWT(tokenString, keys)
+	if err != nil {
+		t.Fatalf("Error with validation: %v", err)
+	}
+	if idValidated != id {
+		t.Fatalf("Expected: %s but got: %s", id, idValidated)
+	}
+}
+
+func TestRotationKeepsOldKeyUntilRetired(t *testing.T) {
+	oldKey, err := GenerateSigningKey(AlgEdDSA)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	keys := NewKeySet(oldKey)
+	id := uuid.New()
+	tokenString, err := MakeJWT(id, keys, time.Minute)
+	if err != nil {
+		t.Fatalf("Error happened during making: %v", err)
+	}
+
+	// A new key that isn't signing yet is published but not used.
+	newKey, err := GenerateSigningKey(AlgRS256)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	newKey.NotBefore = time.Now().Add(time.Hour)
+	keys.Replace([]SigningKey{oldKey, newKey})
+	if k, err := keys.signer(time.Now()); err != nil || k.ID != oldKey.ID {
+		t.Fatalf("Expected the old key to keep signing, got %q (%v)", k.ID, err)
+	}
+	if n := len(keys.JWKS(time.Now()).Keys); n != 2 {
+		t.Fatalf("Expected both keys published but got %d", n)
+	}
+
+	// Once the new key is signing, tokens from the old one still validate.
+	newKey.NotBefore = time.Now().Add(-time.Second)
+	oldKey.RetiresAt = time.Now().Add(time.Hour)
+	keys.Replace([]SigningKey{oldKey, newKey})
+	if k, err := keys.signer(time.Now()); err != nil || k.ID != newKey.ID {
+		t.Fatalf("Expected the new key to sign, got %q (%v)", k.ID, err)
+	}
+	if _, err := ValidateJWT(tokenString, keys); err != nil {
+		t.Fatalf("Expected token from the old key to validate: %v", err)
+	}
+
+	// After retirement they're rejected and the key is unpublished.
+	oldKey.RetiresAt = time.Now().Add(-time.Second)
+	keys.Replace([]SigningKey{oldKey, newKey})
+	if _, err := ValidateJWT(tokenString, keys); err == nil {
+		t.Fatalf("Expected token from a retired key to be rejected")
+	}
+	jwks := keys.JWKS(time.Now())
+	if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != newKey.ID || jwks.Keys[0].Kty != "RSA" {
+		t.Fatalf("Expected only the new key published but got %+v", jwks.Keys)
+	}
+}
+
+func TestNoSigningKey(t *testing.T) {
+	if _, err := MakeJWT(uuid.New(), NewKeySet(), time.Minute); err == nil {
+		t.Fatalf("Expected an error without a signing key")
+	}
+}
+
+func TestPrivateKeyRoundTrip(t *testing.T) {
+	for _, alg := range []string{AlgEdDSA, AlgRS256} {
+		k, err := GenerateSigningKey(alg)
+		if err != nil {
+			t.Fatalf("Error generating %s key: %v", alg, err)
+		}
+		der, err := MarshalPrivateKey(k)
+		if err != nil {
+			t.Fatalf("Error marshalling %s key: %v", alg, err)
+		}
+		if _, err := ParsePrivateKey(alg, der); err != nil {
+			t.Fatalf("Error parsing %s key: %v", alg, err)
+		}
+	}
+
+	k, err := GenerateSigningKey(AlgEdDSA)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	der, err := MarshalPrivateKey(k)
+	if err != nil {
+		t.Fatalf("Error marshalling key: %v", err)
+	}
+	if _, err := ParsePrivateKey(AlgRS256, der); err == nil {
+		t.Fatalf("Expected an Ed25519 key to be refused as RS256")
+	}
+}
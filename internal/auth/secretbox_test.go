@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSecretBoxRoundTrip(t *testing.T) {
+	box, err := NewSecretBox(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("Error making box: %v", err)
+	}
+
+	sealed, err := box.Seal([]byte("JBSWY3DPEHPK3PXP"), []byte("user-1"))
+	if err != nil {
+		t.Fatalf("Error sealing: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("JBSWY3DPEHPK3PXP")) {
+		t.Fatalf("Expected the plaintext not to appear in the sealed secret")
+	}
+
+	opened, err := box.Open(sealed, []byte("user-1"))
+	if err != nil {
+		t.Fatalf("Error opening: %v", err)
+	}
+	if string(opened) != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("Expected the original secret but got: %s", opened)
+	}
+
+	if _, err := box.Open(sealed, []byte("user-2")); err == nil {
+		t.Fatalf("Expected opening with a different context to fail")
+	}
+}
+
+func TestSecretBoxKeyLength(t *testing.T) {
+	if _, err := NewSecretBox([]byte("short")); err == nil {
+		t.Fatalf("Expected a short key to be refused")
+	}
+}
@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// MakeOpaqueToken returns a random token to hand to a client, for things
+// like login challenges that are looked up rather than verified.
+func MakeOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken is how opaque tokens are stored, so a copy of the database
+// doesn't hand out working tokens. They're random enough that a plain
+// SHA-256 is all they need.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,160 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: bookmarks.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const bookmarkChirp = `-- name: BookmarkChirp :exec
+INSERT INTO chirp_bookmarks (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING
+`
+
+type BookmarkChirpParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) BookmarkChirp(ctx context.Context, arg BookmarkChirpParams) error {
+	_, err := q.db.ExecContext(ctx, bookmarkChirp, arg.UserID, arg.ChirpID)
+	return err
+}
+
+const getBookmarkedChirpIDs = `-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id
+FROM chirp_bookmarks
+WHERE user_id = $1 AND chirp_id = ANY($2::uuid[])
+`
+
+type GetBookmarkedChirpIDsParams struct {
+	UserID   uuid.UUID
+	ChirpIds []uuid.UUID
+}
+
+func (q *Queries) GetBookmarkedChirpIDs(ctx context.Context, arg GetBookmarkedChirpIDsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getBookmarkedChirpIDs, arg.UserID, pq.Array(arg.ChirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var chirp_id uuid.UUID
+		if err := rows.Scan(&chirp_id); err != nil {
+			return nil, err
+		}
+		items = append(items, chirp_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserBookmarks = `-- name: GetUserBookmarks :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.quote_of, chirps.search_vector, chirps.deleted_at, chirps.pinned_at, chirps.visibility, chirps.expires_at, chirps.content_warning, chirps.sensitive, chirps.sensitive_by_moderator, chirp_bookmarks.created_at AS bookmarked_at
+FROM chirp_bookmarks
+JOIN chirps ON chirps.id = chirp_bookmarks.chirp_id
+WHERE chirp_bookmarks.user_id = $1
+    AND chirps.deleted_at IS NULL
+    AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $1)
+    AND (
+        $2::timestamp IS NULL
+        OR (chirp_bookmarks.created_at, chirps.id) < ($2::timestamp, $3::uuid)
+    )
+ORDER BY chirp_bookmarks.created_at DESC, chirps.id DESC
+LIMIT $4
+`
+
+type GetUserBookmarksParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       sql.NullInt32
+}
+
+type GetUserBookmarksRow struct {
+	Chirp        Chirp
+	BookmarkedAt time.Time
+}
+
+// Newest bookmark first. The cursor is the bookmark's timestamp rather than
+// the chirp's, since that's what the list is ordered by.
+func (q *Queries) GetUserBookmarks(ctx context.Context, arg GetUserBookmarksParams) ([]GetUserBookmarksRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserBookmarks,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserBookmarksRow
+	for rows.Next() {
+		var i GetUserBookmarksRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.QuoteOf,
+			&i.Chirp.SearchVector,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.PinnedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ExpiresAt,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.SensitiveByModerator,
+			&i.BookmarkedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const unbookmarkChirp = `-- name: UnbookmarkChirp :exec
+DELETE
+FROM chirp_bookmarks
+WHERE user_id = $1 AND chirp_id = $2
+`
+
+type UnbookmarkChirpParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) UnbookmarkChirp(ctx context.Context, arg UnbookmarkChirpParams) error {
+	_, err := q.db.ExecContext(ctx, unbookmarkChirp, arg.UserID, arg.ChirpID)
+	return err
+}
@@ -10,7 +10,7 @@ import (
 )
 
 const getTokenData = `-- name: GetTokenData :one
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at
+SELECT token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, replaced_by, user_agent, ip_address, last_used_at
 FROM refresh_tokens
 WHERE token = $1
 `
@@ -25,6 +25,11 @@ func (q *Queries) GetTokenData(ctx context.Context, token string) (RefreshToken,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,302 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: chirp_drafts.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const claimDueDraft = `-- name: ClaimDueDraft :one
+SELECT id, created_at, updated_at, user_id, body, in_reply_to, quote_of, publish_at, last_error, media_ids, visibility, content_warning, sensitive
+FROM chirp_drafts
+WHERE publish_at <= $1::timestamp
+ORDER BY publish_at ASC
+LIMIT 1
+FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) ClaimDueDraft(ctx context.Context, now time.Time) (ChirpDraft, error) {
+	row := q.db.QueryRowContext(ctx, claimDueDraft, now)
+	var i ChirpDraft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.PublishAt,
+		&i.LastError,
+		pq.Array(&i.MediaIds),
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+	)
+	return i, err
+}
+
+const createDraft = `-- name: CreateDraft :one
+INSERT INTO chirp_drafts (id, created_at, updated_at, user_id, body, in_reply_to, quote_of, publish_at, media_ids, visibility, content_warning, sensitive)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING id, created_at, updated_at, user_id, body, in_reply_to, quote_of, publish_at, last_error, media_ids, visibility, content_warning, sensitive
+`
+
+type CreateDraftParams struct {
+	UserID         uuid.UUID
+	Body           string
+	InReplyTo      uuid.NullUUID
+	QuoteOf        uuid.NullUUID
+	PublishAt      sql.NullTime
+	MediaIds       []uuid.UUID
+	Visibility     string
+	ContentWarning sql.NullString
+	Sensitive      bool
+}
+
+func (q *Queries) CreateDraft(ctx context.Context, arg CreateDraftParams) (ChirpDraft, error) {
+	row := q.db.QueryRowContext(ctx, createDraft,
+		arg.UserID,
+		arg.Body,
+		arg.InReplyTo,
+		arg.QuoteOf,
+		arg.PublishAt,
+		pq.Array(arg.MediaIds),
+		arg.Visibility,
+		arg.ContentWarning,
+		arg.Sensitive,
+	)
+	var i ChirpDraft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.PublishAt,
+		&i.LastError,
+		pq.Array(&i.MediaIds),
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+	)
+	return i, err
+}
+
+const deleteDraft = `-- name: DeleteDraft :execrows
+DELETE FROM chirp_drafts
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteDraftParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteDraft(ctx context.Context, arg DeleteDraftParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDraft, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getDraft = `-- name: GetDraft :one
+SELECT id, created_at, updated_at, user_id, body, in_reply_to, quote_of, publish_at, last_error, media_ids, visibility, content_warning, sensitive
+FROM chirp_drafts
+WHERE id = $1 AND user_id = $2
+`
+
+type GetDraftParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetDraft(ctx context.Context, arg GetDraftParams) (ChirpDraft, error) {
+	row := q.db.QueryRowContext(ctx, getDraft, arg.ID, arg.UserID)
+	var i ChirpDraft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.PublishAt,
+		&i.LastError,
+		pq.Array(&i.MediaIds),
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+	)
+	return i, err
+}
+
+const getUserDrafts = `-- name: GetUserDrafts :many
+SELECT id, created_at, updated_at, user_id, body, in_reply_to, quote_of, publish_at, last_error, media_ids, visibility, content_warning, sensitive
+FROM chirp_drafts
+WHERE user_id = $1
+ORDER BY updated_at DESC
+`
+
+func (q *Queries) GetUserDrafts(ctx context.Context, userID uuid.UUID) ([]ChirpDraft, error) {
+	rows, err := q.db.QueryContext(ctx, getUserDrafts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpDraft
+	for rows.Next() {
+		var i ChirpDraft
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.InReplyTo,
+			&i.QuoteOf,
+			&i.PublishAt,
+			&i.LastError,
+			pq.Array(&i.MediaIds),
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockDraft = `-- name: LockDraft :one
+SELECT id, created_at, updated_at, user_id, body, in_reply_to, quote_of, publish_at, last_error, media_ids, visibility, content_warning, sensitive
+FROM chirp_drafts
+WHERE id = $1 AND user_id = $2
+FOR UPDATE
+`
+
+type LockDraftParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) LockDraft(ctx context.Context, arg LockDraftParams) (ChirpDraft, error) {
+	row := q.db.QueryRowContext(ctx, lockDraft, arg.ID, arg.UserID)
+	var i ChirpDraft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.PublishAt,
+		&i.LastError,
+		pq.Array(&i.MediaIds),
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+	)
+	return i, err
+}
+
+const unscheduleDraft = `-- name: UnscheduleDraft :exec
+UPDATE chirp_drafts
+SET publish_at = NULL, last_error = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type UnscheduleDraftParams struct {
+	ID        uuid.UUID
+	LastError sql.NullString
+}
+
+func (q *Queries) UnscheduleDraft(ctx context.Context, arg UnscheduleDraftParams) error {
+	_, err := q.db.ExecContext(ctx, unscheduleDraft, arg.ID, arg.LastError)
+	return err
+}
+
+const updateDraft = `-- name: UpdateDraft :one
+UPDATE chirp_drafts
+SET body = $3, in_reply_to = $4, quote_of = $5, publish_at = $6, media_ids = $7, visibility = $8, content_warning = $9, sensitive = $10, last_error = NULL, updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING id, created_at, updated_at, user_id, body, in_reply_to, quote_of, publish_at, last_error, media_ids, visibility, content_warning, sensitive
+`
+
+type UpdateDraftParams struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	Body           string
+	InReplyTo      uuid.NullUUID
+	QuoteOf        uuid.NullUUID
+	PublishAt      sql.NullTime
+	MediaIds       []uuid.UUID
+	Visibility     string
+	ContentWarning sql.NullString
+	Sensitive      bool
+}
+
+func (q *Queries) UpdateDraft(ctx context.Context, arg UpdateDraftParams) (ChirpDraft, error) {
+	row := q.db.QueryRowContext(ctx, updateDraft,
+		arg.ID,
+		arg.UserID,
+		arg.Body,
+		arg.InReplyTo,
+		arg.QuoteOf,
+		arg.PublishAt,
+		pq.Array(arg.MediaIds),
+		arg.Visibility,
+		arg.ContentWarning,
+		arg.Sensitive,
+	)
+	var i ChirpDraft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.PublishAt,
+		&i.LastError,
+		pq.Array(&i.MediaIds),
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+	)
+	return i, err
+}
@@ -0,0 +1,146 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: chirp_revisions.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const countChirpRevisions = `-- name: CountChirpRevisions :one
+SELECT COUNT(*) FROM chirp_revisions
+WHERE chirp_id = $1
+`
+
+func (q *Queries) CountChirpRevisions(ctx context.Context, chirpID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countChirpRevisions, chirpID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createChirpRevision = `-- name: CreateChirpRevision :exec
+INSERT INTO chirp_revisions (id, chirp_id, revision, body, created_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    COALESCE((SELECT MAX(revision) FROM chirp_revisions WHERE chirp_id = $1), 0) + 1,
+    $2,
+    $3
+)
+`
+
+type CreateChirpRevisionParams struct {
+	ChirpID   uuid.UUID
+	Body      string
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateChirpRevision(ctx context.Context, arg CreateChirpRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, createChirpRevision, arg.ChirpID, arg.Body, arg.CreatedAt)
+	return err
+}
+
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, quote_of, search_vector, deleted_at, pinned_at, visibility, expires_at, content_warning, sensitive, sensitive_by_moderator FROM chirps
+WHERE id = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+FOR UPDATE
+`
+
+func (q *Queries) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.SearchVector,
+		&i.DeletedAt,
+		&i.PinnedAt,
+		&i.Visibility,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveByModerator,
+	)
+	return i, err
+}
+
+const getChirpRevisions = `-- name: GetChirpRevisions :many
+SELECT id, chirp_id, revision, body, created_at FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY revision ASC
+`
+
+func (q *Queries) GetChirpRevisions(ctx context.Context, chirpID uuid.UUID) ([]ChirpRevision, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpRevisions, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpRevision
+	for rows.Next() {
+		var i ChirpRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChirpID,
+			&i.Revision,
+			&i.Body,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, quote_of, search_vector, deleted_at, pinned_at, visibility, expires_at, content_warning, sensitive, sensitive_by_moderator
+`
+
+type UpdateChirpBodyParams struct {
+	ID   uuid.UUID
+	Body string
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.SearchVector,
+		&i.DeletedAt,
+		&i.PinnedAt,
+		&i.Visibility,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveByModerator,
+	)
+	return i, err
+}
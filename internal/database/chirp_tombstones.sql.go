@@ -0,0 +1,63 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: chirp_tombstones.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createChirpTombstone = `-- name: CreateChirpTombstone :exec
+INSERT INTO chirp_tombstones (id, created_at, deleted_at, in_reply_to)
+VALUES (
+    $1,
+    $2,
+    NOW(),
+    $3
+)
+ON CONFLICT (id) DO NOTHING
+`
+
+type CreateChirpTombstoneParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	InReplyTo uuid.NullUUID
+}
+
+func (q *Queries) CreateChirpTombstone(ctx context.Context, arg CreateChirpTombstoneParams) error {
+	_, err := q.db.ExecContext(ctx, createChirpTombstone, arg.ID, arg.CreatedAt, arg.InReplyTo)
+	return err
+}
+
+const deleteChirpTombstone = `-- name: DeleteChirpTombstone :exec
+DELETE FROM chirp_tombstones
+WHERE id = $1
+`
+
+func (q *Queries) DeleteChirpTombstone(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteChirpTombstone, id)
+	return err
+}
+
+const getChirpTombstone = `-- name: GetChirpTombstone :one
+SELECT id, created_at, deleted_at, in_reply_to
+FROM chirp_tombstones
+WHERE id = $1
+`
+
+func (q *Queries) GetChirpTombstone(ctx context.Context, id uuid.UUID) (ChirpTombstone, error) {
+	row := q.db.QueryRowContext(ctx, getChirpTombstone, id)
+	var i ChirpTombstone
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.InReplyTo,
+	)
+	return i, err
+}
@@ -0,0 +1,156 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: chirp_trash.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const getTrashedChirp = `-- name: GetTrashedChirp :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, quote_of, search_vector, deleted_at, pinned_at, visibility, expires_at, content_warning, sensitive, sensitive_by_moderator
+FROM chirps
+WHERE id = $1
+    AND deleted_at IS NOT NULL
+    AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+`
+
+func (q *Queries) GetTrashedChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getTrashedChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.SearchVector,
+		&i.DeletedAt,
+		&i.PinnedAt,
+		&i.Visibility,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveByModerator,
+	)
+	return i, err
+}
+
+const getUserTrash = `-- name: GetUserTrash :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, quote_of, search_vector, deleted_at, pinned_at, visibility, expires_at, content_warning, sensitive, sensitive_by_moderator
+FROM chirps
+WHERE user_id = $1
+    AND deleted_at > LOCALTIMESTAMP - make_interval(secs => $2::float8)
+    AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+ORDER BY deleted_at DESC
+`
+
+type GetUserTrashParams struct {
+	UserID           uuid.NullUUID
+	RetentionSeconds float64
+}
+
+func (q *Queries) GetUserTrash(ctx context.Context, arg GetUserTrashParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getUserTrash, arg.UserID, arg.RetentionSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.QuoteOf,
+			&i.SearchVector,
+			&i.DeletedAt,
+			&i.PinnedAt,
+			&i.Visibility,
+			&i.ExpiresAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveByModerator,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeExpiredChirps = `-- name: PurgeExpiredChirps :execrows
+DELETE FROM chirps
+WHERE id IN (
+    SELECT id
+    FROM chirps
+    WHERE deleted_at <= LOCALTIMESTAMP - make_interval(secs => $1::float8)
+    LIMIT $2
+)
+`
+
+type PurgeExpiredChirpsParams struct {
+	RetentionSeconds float64
+	BatchSize        int32
+}
+
+func (q *Queries) PurgeExpiredChirps(ctx context.Context, arg PurgeExpiredChirpsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeExpiredChirps, arg.RetentionSeconds, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = $1
+    AND deleted_at > LOCALTIMESTAMP - make_interval(secs => $2::float8)
+    AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, quote_of, search_vector, deleted_at, pinned_at, visibility, expires_at, content_warning, sensitive, sensitive_by_moderator
+`
+
+type RestoreChirpParams struct {
+	ID               uuid.UUID
+	RetentionSeconds float64
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, arg.ID, arg.RetentionSeconds)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.SearchVector,
+		&i.DeletedAt,
+		&i.PinnedAt,
+		&i.Visibility,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveByModerator,
+	)
+	return i, err
+}
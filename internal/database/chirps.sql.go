@@ -7,29 +7,51 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, quote_of, visibility, expires_at, content_warning, sensitive)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    NOW() + make_interval(secs => $6::float8),
+    $7,
+    $8
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, quote_of, search_vector, deleted_at, pinned_at, visibility, expires_at, content_warning, sensitive, sensitive_by_moderator
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.NullUUID
+	Body             string
+	UserID           uuid.NullUUID
+	InReplyTo        uuid.NullUUID
+	QuoteOf          uuid.NullUUID
+	Visibility       string
+	ExpiresInSeconds sql.NullFloat64
+	ContentWarning   sql.NullString
+	Sensitive        bool
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyTo,
+		arg.QuoteOf,
+		arg.Visibility,
+		arg.ExpiresInSeconds,
+		arg.ContentWarning,
+		arg.Sensitive,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +59,16 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.SearchVector,
+		&i.DeletedAt,
+		&i.PinnedAt,
+		&i.Visibility,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveByModerator,
 	)
 	return i, err
 }
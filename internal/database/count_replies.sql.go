@@ -0,0 +1,69 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: count_replies.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const countReplies = `-- name: CountReplies :many
+SELECT in_reply_to, COUNT(*) AS reply_count
+FROM chirps
+WHERE in_reply_to = ANY($1::uuid[]) AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(id, user_id, visibility, $2::uuid)
+GROUP BY in_reply_to
+`
+
+type CountRepliesParams struct {
+	ChirpIds []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+type CountRepliesRow struct {
+	InReplyTo  uuid.NullUUID
+	ReplyCount int64
+}
+
+func (q *Queries) CountReplies(ctx context.Context, arg CountRepliesParams) ([]CountRepliesRow, error) {
+	rows, err := q.db.QueryContext(ctx, countReplies, pq.Array(arg.ChirpIds), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRepliesRow
+	for rows.Next() {
+		var i CountRepliesRow
+		if err := rows.Scan(&i.InReplyTo, &i.ReplyCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hasReplies = `-- name: HasReplies :one
+SELECT EXISTS (
+    SELECT 1
+    FROM chirps
+    WHERE in_reply_to = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+)
+`
+
+func (q *Queries) HasReplies(ctx context.Context, inReplyTo uuid.NullUUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasReplies, inReplyTo)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
@@ -12,9 +12,9 @@ import (
 )
 
 const deleteChirp = `-- name: DeleteChirp :exec
-DELETE 
-FROM chirps
-WHERE id = $1
+UPDATE chirps
+SET deleted_at = NOW(), pinned_at = NULL
+WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
@@ -0,0 +1,56 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: ephemeral_chirps.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const claimExpiredChirp = `-- name: ClaimExpiredChirp :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, quote_of, search_vector, deleted_at, pinned_at, visibility, expires_at, content_warning, sensitive, sensitive_by_moderator
+FROM chirps
+WHERE expires_at <= LOCALTIMESTAMP
+ORDER BY expires_at ASC
+LIMIT 1
+FOR UPDATE SKIP LOCKED
+`
+
+// Ephemeral chirps are claimed one at a time, whether or not their author
+// already put them in the trash, since an expired chirp can't be restored.
+func (q *Queries) ClaimExpiredChirp(ctx context.Context) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, claimExpiredChirp)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.SearchVector,
+		&i.DeletedAt,
+		&i.PinnedAt,
+		&i.Visibility,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveByModerator,
+	)
+	return i, err
+}
+
+const deleteChirpPermanently = `-- name: DeleteChirpPermanently :exec
+DELETE FROM chirps
+WHERE id = $1
+`
+
+func (q *Queries) DeleteChirpPermanently(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteChirpPermanently, id)
+	return err
+}
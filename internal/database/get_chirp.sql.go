@@ -12,9 +12,9 @@ import (
 )
 
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, quote_of, search_vector, deleted_at, pinned_at, visibility, expires_at, content_warning, sensitive, sensitive_by_moderator
 FROM chirps
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
 `
 
 func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -26,6 +26,53 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.SearchVector,
+		&i.DeletedAt,
+		&i.PinnedAt,
+		&i.Visibility,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveByModerator,
+	)
+	return i, err
+}
+
+const getVisibleChirp = `-- name: GetVisibleChirp :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, quote_of, search_vector, deleted_at, pinned_at, visibility, expires_at, content_warning, sensitive, sensitive_by_moderator
+FROM chirps
+WHERE id = $1
+    AND deleted_at IS NULL
+    AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(id, user_id, visibility, $2::uuid)
+`
+
+type GetVisibleChirpParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetVisibleChirp(ctx context.Context, arg GetVisibleChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getVisibleChirp, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.SearchVector,
+		&i.DeletedAt,
+		&i.PinnedAt,
+		&i.Visibility,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveByModerator,
 	)
 	return i, err
 }
@@ -0,0 +1,73 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: get_chirp_descendants.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE thread AS (
+    SELECT nodes.id, nodes.in_reply_to, nodes.created_at, 1 AS depth
+    FROM (
+        SELECT chirps.id, chirps.in_reply_to, chirps.created_at FROM chirps WHERE chirps.deleted_at IS NULL AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP) AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $1::uuid)
+        UNION ALL
+        SELECT chirp_tombstones.id, chirp_tombstones.in_reply_to, chirp_tombstones.created_at FROM chirp_tombstones
+    ) AS nodes
+    WHERE nodes.in_reply_to = $2::uuid
+    UNION ALL
+    SELECT nodes.id, nodes.in_reply_to, nodes.created_at, thread.depth + 1
+    FROM (
+        SELECT chirps.id, chirps.in_reply_to, chirps.created_at FROM chirps WHERE chirps.deleted_at IS NULL AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP) AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $1::uuid)
+        UNION ALL
+        SELECT chirp_tombstones.id, chirp_tombstones.in_reply_to, chirp_tombstones.created_at FROM chirp_tombstones
+    ) AS nodes
+    JOIN thread ON nodes.in_reply_to = thread.id
+    WHERE thread.depth < $3::int
+)
+SELECT thread.id, thread.in_reply_to, thread.depth
+FROM thread
+ORDER BY thread.depth ASC, thread.created_at ASC
+`
+
+type GetChirpDescendantsParams struct {
+	ViewerID uuid.NullUUID
+	ChirpID  uuid.UUID
+	MaxDepth int32
+}
+
+type GetChirpDescendantsRow struct {
+	ID        uuid.UUID
+	InReplyTo uuid.NullUUID
+	Depth     int32
+}
+
+// Replies the viewer can't see are left out along with everything under
+// them, so a hidden chirp doesn't show up as a gap in the thread.
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]GetChirpDescendantsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants, arg.ViewerID, arg.ChirpID, arg.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpDescendantsRow
+	for rows.Next() {
+		var i GetChirpDescendantsRow
+		if err := rows.Scan(&i.ID, &i.InReplyTo, &i.Depth); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
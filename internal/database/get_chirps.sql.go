@@ -7,18 +7,49 @@ package database
 
 import (
 	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
 )
 
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, quote_of, search_vector, deleted_at, pinned_at, visibility, expires_at, content_warning, sensitive, sensitive_by_moderator
 FROM chirps
+WHERE deleted_at IS NULL
+    AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(id, user_id, visibility, $1::uuid)
+    AND ($2::boolean OR NOT sensitive)
+    AND (
+        $3::timestamp IS NULL
+        OR ($4 = 'desc' AND (created_at, id) < ($3::timestamp, $5::uuid))
+        OR ($4 = 'asc' AND (created_at, id) > ($3::timestamp, $5::uuid))
+    )
 ORDER BY 
-    CASE WHEN $1 = 'desc' THEN created_at END DESC,
-    CASE WHEN $1 = 'asc' THEN created_at END ASC
+    CASE WHEN $4 = 'desc' THEN created_at END DESC,
+    CASE WHEN $4 = 'desc' THEN id END DESC,
+    CASE WHEN $4 = 'asc' THEN created_at END ASC,
+    CASE WHEN $4 = 'asc' THEN id END ASC
+LIMIT $6
 `
 
-func (q *Queries) GetChirps(ctx context.Context, sortOrder interface{}) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps, sortOrder)
+type GetChirpsParams struct {
+	ViewerID         uuid.NullUUID
+	IncludeSensitive bool
+	CursorCreatedAt  sql.NullTime
+	SortOrder        interface{}
+	CursorID         uuid.NullUUID
+	PageLimit        sql.NullInt32
+}
+
+func (q *Queries) GetChirps(ctx context.Context, arg GetChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirps,
+		arg.ViewerID,
+		arg.IncludeSensitive,
+		arg.CursorCreatedAt,
+		arg.SortOrder,
+		arg.CursorID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -32,6 +63,16 @@ func (q *Queries) GetChirps(ctx context.Context, sortOrder interface{}) ([]Chirp
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.QuoteOf,
+			&i.SearchVector,
+			&i.DeletedAt,
+			&i.PinnedAt,
+			&i.Visibility,
+			&i.ExpiresAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveByModerator,
 		); err != nil {
 			return nil, err
 		}
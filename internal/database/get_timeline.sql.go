@@ -0,0 +1,110 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: get_timeline.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const getTimeline = `-- name: GetTimeline :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.quote_of, chirps.search_vector, chirps.deleted_at, chirps.pinned_at, chirps.visibility, chirps.expires_at, chirps.content_warning, chirps.sensitive, chirps.sensitive_by_moderator, feed.feed_at, feed.entry_id, feed.rechirped_by
+FROM (
+    SELECT chirps.id AS chirp_id, chirps.created_at AS feed_at, chirps.id AS entry_id, NULL::uuid AS rechirped_by
+    FROM chirps
+    JOIN follows ON follows.followee_id = chirps.user_id
+    WHERE follows.follower_id = $1
+    UNION ALL
+    SELECT rechirps.chirp_id, rechirps.created_at, rechirps.id, rechirps.user_id
+    FROM rechirps
+    JOIN follows ON follows.followee_id = rechirps.user_id
+    WHERE follows.follower_id = $1
+) AS feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+    AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $1)
+    AND (
+        $2::timestamp IS NULL
+        OR ($3 = 'desc' AND (feed.feed_at, feed.entry_id) < ($2::timestamp, $4::uuid))
+        OR ($3 = 'asc' AND (feed.feed_at, feed.entry_id) > ($2::timestamp, $4::uuid))
+    )
+ORDER BY 
+    CASE WHEN $3 = 'desc' THEN feed.feed_at END DESC,
+    CASE WHEN $3 = 'desc' THEN feed.entry_id END DESC,
+    CASE WHEN $3 = 'asc' THEN feed.feed_at END ASC,
+    CASE WHEN $3 = 'asc' THEN feed.entry_id END ASC
+LIMIT $5
+`
+
+type GetTimelineParams struct {
+	FollowerID      uuid.UUID
+	CursorCreatedAt sql.NullTime
+	SortOrder       interface{}
+	CursorID        uuid.NullUUID
+	PageLimit       sql.NullInt32
+}
+
+type GetTimelineRow struct {
+	Chirp       Chirp
+	FeedAt      time.Time
+	EntryID     uuid.UUID
+	RechirpedBy uuid.NullUUID
+}
+
+// The followed users' chirps and rechirps, merged into one feed ordered by
+// when each entry was posted or shared. A rechirp entry has its own ID and
+// rechirped_by set.
+func (q *Queries) GetTimeline(ctx context.Context, arg GetTimelineParams) ([]GetTimelineRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTimeline,
+		arg.FollowerID,
+		arg.CursorCreatedAt,
+		arg.SortOrder,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTimelineRow
+	for rows.Next() {
+		var i GetTimelineRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.QuoteOf,
+			&i.Chirp.SearchVector,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.PinnedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ExpiresAt,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.SensitiveByModerator,
+			&i.FeedAt,
+			&i.EntryID,
+			&i.RechirpedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
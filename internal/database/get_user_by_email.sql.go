@@ -10,7 +10,7 @@ import (
 )
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, show_sensitive, is_moderator, totp_secret, totp_enabled_at, totp_last_step, mfa_attempts, mfa_locked_until
 FROM users
 WHERE email = $1
 `
@@ -25,6 +25,14 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.ShowSensitive,
+		&i.IsModerator,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.MfaAttempts,
+		&i.MfaLockedUntil,
 	)
 	return i, err
 }
@@ -7,39 +7,96 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 const getUserChirps = `-- name: GetUserChirps :many
-SELECT id, created_at, updated_at, body, user_id
-FROM chirps
-WHERE $1 = user_id
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.quote_of, chirps.search_vector, chirps.deleted_at, chirps.pinned_at, chirps.visibility, chirps.expires_at, chirps.content_warning, chirps.sensitive, chirps.sensitive_by_moderator, feed.feed_at, feed.entry_id, feed.rechirped_by
+FROM (
+    SELECT chirps.id AS chirp_id, chirps.created_at AS feed_at, chirps.id AS entry_id, NULL::uuid AS rechirped_by
+    FROM chirps
+    WHERE chirps.user_id = $1
+    UNION ALL
+    SELECT rechirps.chirp_id, rechirps.created_at, rechirps.id, rechirps.user_id
+    FROM rechirps
+    WHERE rechirps.user_id = $1
+) AS feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+    AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $2::uuid)
+    AND ($3::boolean OR NOT chirps.sensitive)
+    AND (
+        $4::timestamp IS NULL
+        OR ($5 = 'desc' AND (feed.feed_at, feed.entry_id) < ($4::timestamp, $6::uuid))
+        OR ($5 = 'asc' AND (feed.feed_at, feed.entry_id) > ($4::timestamp, $6::uuid))
+    )
 ORDER BY 
-    CASE WHEN $2 = 'desc' THEN created_at END DESC,
-    CASE WHEN $2 = 'asc' THEN created_at END ASC
+    CASE WHEN $5 = 'desc' THEN feed.feed_at END DESC,
+    CASE WHEN $5 = 'desc' THEN feed.entry_id END DESC,
+    CASE WHEN $5 = 'asc' THEN feed.feed_at END ASC,
+    CASE WHEN $5 = 'asc' THEN feed.entry_id END ASC
+LIMIT $7
 `
 
 type GetUserChirpsParams struct {
-	UserID    uuid.NullUUID
-	SortOrder interface{}
+	UserID           uuid.NullUUID
+	ViewerID         uuid.NullUUID
+	IncludeSensitive bool
+	CursorCreatedAt  sql.NullTime
+	SortOrder        interface{}
+	CursorID         uuid.NullUUID
+	PageLimit        sql.NullInt32
 }
 
-func (q *Queries) GetUserChirps(ctx context.Context, arg GetUserChirpsParams) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getUserChirps, arg.UserID, arg.SortOrder)
+type GetUserChirpsRow struct {
+	Chirp       Chirp
+	FeedAt      time.Time
+	EntryID     uuid.UUID
+	RechirpedBy uuid.NullUUID
+}
+
+// The user's own chirps and their rechirps, merged the same way as
+// GetTimeline.
+func (q *Queries) GetUserChirps(ctx context.Context, arg GetUserChirpsParams) ([]GetUserChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserChirps,
+		arg.UserID,
+		arg.ViewerID,
+		arg.IncludeSensitive,
+		arg.CursorCreatedAt,
+		arg.SortOrder,
+		arg.CursorID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Chirp
+	var items []GetUserChirpsRow
 	for rows.Next() {
-		var i Chirp
+		var i GetUserChirpsRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Body,
-			&i.UserID,
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.QuoteOf,
+			&i.Chirp.SearchVector,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.PinnedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ExpiresAt,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.SensitiveByModerator,
+			&i.FeedAt,
+			&i.EntryID,
+			&i.RechirpedBy,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,232 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: hashtags.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const addChirpHashtag = `-- name: AddChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (chirp_id, hashtag_id) DO NOTHING
+`
+
+type AddChirpHashtagParams struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+}
+
+func (q *Queries) AddChirpHashtag(ctx context.Context, arg AddChirpHashtagParams) error {
+	_, err := q.db.ExecContext(ctx, addChirpHashtag, arg.ChirpID, arg.HashtagID)
+	return err
+}
+
+const deleteStaleChirpHashtags = `-- name: DeleteStaleChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1
+    AND NOT (hashtag_id = ANY($2::uuid[]))
+`
+
+type DeleteStaleChirpHashtagsParams struct {
+	ChirpID uuid.UUID
+	KeepIds []uuid.UUID
+}
+
+func (q *Queries) DeleteStaleChirpHashtags(ctx context.Context, arg DeleteStaleChirpHashtagsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteStaleChirpHashtags, arg.ChirpID, pq.Array(arg.KeepIds))
+	return err
+}
+
+const getChirpHashtags = `-- name: GetChirpHashtags :many
+SELECT chirp_hashtags.chirp_id, hashtags.tag
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE chirp_hashtags.chirp_id = ANY($1::uuid[])
+ORDER BY hashtags.tag
+`
+
+type GetChirpHashtagsRow struct {
+	ChirpID uuid.UUID
+	Tag     string
+}
+
+func (q *Queries) GetChirpHashtags(ctx context.Context, chirpIds []uuid.UUID) ([]GetChirpHashtagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpHashtags, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpHashtagsRow
+	for rows.Next() {
+		var i GetChirpHashtagsRow
+		if err := rows.Scan(&i.ChirpID, &i.Tag); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHashtagChirps = `-- name: GetHashtagChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.quote_of, chirps.search_vector, chirps.deleted_at, chirps.pinned_at, chirps.visibility, chirps.expires_at, chirps.content_warning, chirps.sensitive, chirps.sensitive_by_moderator
+FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.tag = $1
+    AND chirps.deleted_at IS NULL
+    AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $2::uuid)
+    AND (
+        $3::timestamp IS NULL
+        OR ($4 = 'desc' AND (chirps.created_at, chirps.id) < ($3::timestamp, $5::uuid))
+        OR ($4 = 'asc' AND (chirps.created_at, chirps.id) > ($3::timestamp, $5::uuid))
+    )
+ORDER BY 
+    CASE WHEN $4 = 'desc' THEN chirps.created_at END DESC,
+    CASE WHEN $4 = 'desc' THEN chirps.id END DESC,
+    CASE WHEN $4 = 'asc' THEN chirps.created_at END ASC,
+    CASE WHEN $4 = 'asc' THEN chirps.id END ASC
+LIMIT $6
+`
+
+type GetHashtagChirpsParams struct {
+	Tag             string
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	SortOrder       interface{}
+	CursorID        uuid.NullUUID
+	PageLimit       sql.NullInt32
+}
+
+func (q *Queries) GetHashtagChirps(ctx context.Context, arg GetHashtagChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getHashtagChirps,
+		arg.Tag,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.SortOrder,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.QuoteOf,
+			&i.SearchVector,
+			&i.DeletedAt,
+			&i.PinnedAt,
+			&i.Visibility,
+			&i.ExpiresAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveByModerator,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrendingHashtags = `-- name: GetTrendingHashtags :many
+SELECT hashtags.tag,
+    COUNT(*) AS uses,
+    SUM(EXP(-LN(2) * EXTRACT(EPOCH FROM (LOCALTIMESTAMP - chirp_hashtags.created_at)) / $1::float8))::float8 AS score
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirps.deleted_at IS NULL
+    AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+    AND chirps.visibility = 'public'
+    AND chirp_hashtags.created_at > LOCALTIMESTAMP - make_interval(secs => $2::float8)
+GROUP BY hashtags.tag
+ORDER BY score DESC, hashtags.tag ASC
+LIMIT $3
+`
+
+type GetTrendingHashtagsParams struct {
+	HalfLifeSeconds float64
+	WindowSeconds   float64
+	MaxTags         int32
+}
+
+type GetTrendingHashtagsRow struct {
+	Tag   string
+	Uses  int64
+	Score float64
+}
+
+func (q *Queries) GetTrendingHashtags(ctx context.Context, arg GetTrendingHashtagsParams) ([]GetTrendingHashtagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTrendingHashtags, arg.HalfLifeSeconds, arg.WindowSeconds, arg.MaxTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTrendingHashtagsRow
+	for rows.Next() {
+		var i GetTrendingHashtagsRow
+		if err := rows.Scan(&i.Tag, &i.Uses, &i.Score); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertHashtag = `-- name: UpsertHashtag :one
+INSERT INTO hashtags (id, created_at, tag)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1
+)
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING id
+`
+
+func (q *Queries) UpsertHashtag(ctx context.Context, tag string) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, upsertHashtag, tag)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
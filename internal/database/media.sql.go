@@ -0,0 +1,219 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: media.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const addChirpMedia = `-- name: AddChirpMedia :exec
+INSERT INTO chirp_media (chirp_id, media_id, position)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+`
+
+type AddChirpMediaParams struct {
+	ChirpID  uuid.UUID
+	MediaID  uuid.UUID
+	Position int32
+}
+
+func (q *Queries) AddChirpMedia(ctx context.Context, arg AddChirpMediaParams) error {
+	_, err := q.db.ExecContext(ctx, addChirpMedia, arg.ChirpID, arg.MediaID, arg.Position)
+	return err
+}
+
+const countUserMedia = `-- name: CountUserMedia :one
+SELECT COUNT(*)
+FROM media
+WHERE user_id = $1 AND id = ANY($2::uuid[])
+`
+
+type CountUserMediaParams struct {
+	UserID uuid.UUID
+	Ids    []uuid.UUID
+}
+
+func (q *Queries) CountUserMedia(ctx context.Context, arg CountUserMediaParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUserMedia, arg.UserID, pq.Array(arg.Ids))
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createMedia = `-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, content_type, blob_key, size_bytes, width, height, thumbnail_key, thumbnail_content_type)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING id, created_at, user_id, content_type, blob_key, size_bytes, width, height, thumbnail_key, thumbnail_content_type
+`
+
+type CreateMediaParams struct {
+	UserID               uuid.UUID
+	ContentType          string
+	BlobKey              string
+	SizeBytes            int32
+	Width                int32
+	Height               int32
+	ThumbnailKey         string
+	ThumbnailContentType string
+}
+
+func (q *Queries) CreateMedia(ctx context.Context, arg CreateMediaParams) (Medium, error) {
+	row := q.db.QueryRowContext(ctx, createMedia,
+		arg.UserID,
+		arg.ContentType,
+		arg.BlobKey,
+		arg.SizeBytes,
+		arg.Width,
+		arg.Height,
+		arg.ThumbnailKey,
+		arg.ThumbnailContentType,
+	)
+	var i Medium
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.ContentType,
+		&i.BlobKey,
+		&i.SizeBytes,
+		&i.Width,
+		&i.Height,
+		&i.ThumbnailKey,
+		&i.ThumbnailContentType,
+	)
+	return i, err
+}
+
+const getChirpMedia = `-- name: GetChirpMedia :many
+SELECT chirp_media.chirp_id, media.id, media.created_at, media.user_id, media.content_type, media.blob_key, media.size_bytes, media.width, media.height, media.thumbnail_key, media.thumbnail_content_type
+FROM chirp_media
+JOIN media ON media.id = chirp_media.media_id
+WHERE chirp_media.chirp_id = ANY($1::uuid[])
+ORDER BY chirp_media.chirp_id, chirp_media.position
+`
+
+type GetChirpMediaRow struct {
+	ChirpID uuid.UUID
+	Medium  Medium
+}
+
+func (q *Queries) GetChirpMedia(ctx context.Context, chirpIds []uuid.UUID) ([]GetChirpMediaRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpMedia, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpMediaRow
+	for rows.Next() {
+		var i GetChirpMediaRow
+		if err := rows.Scan(
+			&i.ChirpID,
+			&i.Medium.ID,
+			&i.Medium.CreatedAt,
+			&i.Medium.UserID,
+			&i.Medium.ContentType,
+			&i.Medium.BlobKey,
+			&i.Medium.SizeBytes,
+			&i.Medium.Width,
+			&i.Medium.Height,
+			&i.Medium.ThumbnailKey,
+			&i.Medium.ThumbnailContentType,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMedia = `-- name: GetMedia :one
+SELECT id, created_at, user_id, content_type, blob_key, size_bytes, width, height, thumbnail_key, thumbnail_content_type
+FROM media
+WHERE id = $1
+`
+
+func (q *Queries) GetMedia(ctx context.Context, id uuid.UUID) (Medium, error) {
+	row := q.db.QueryRowContext(ctx, getMedia, id)
+	var i Medium
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.ContentType,
+		&i.BlobKey,
+		&i.SizeBytes,
+		&i.Width,
+		&i.Height,
+		&i.ThumbnailKey,
+		&i.ThumbnailContentType,
+	)
+	return i, err
+}
+
+const getMediaAccess = `-- name: GetMediaAccess :one
+SELECT
+    EXISTS (
+        SELECT 1
+        FROM chirp_media
+        JOIN chirps ON chirps.id = chirp_media.chirp_id
+        WHERE chirp_media.media_id = $1
+            AND chirps.deleted_at IS NULL
+            AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+            AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $2::uuid)
+    ) AS visible,
+    EXISTS (
+        SELECT 1
+        FROM chirp_media
+        JOIN chirps ON chirps.id = chirp_media.chirp_id
+        WHERE chirp_media.media_id = $1
+            AND chirps.deleted_at IS NULL
+            AND chirps.expires_at IS NULL
+            AND chirps.visibility = 'public'
+    ) AS public
+`
+
+type GetMediaAccessParams struct {
+	MediaID  uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+type GetMediaAccessRow struct {
+	Visible bool
+	Public  bool
+}
+
+// Whether the viewer can see a chirp the media is attached to, and whether
+// one of those chirps is public with no expiry, so anyone can cache it.
+func (q *Queries) GetMediaAccess(ctx context.Context, arg GetMediaAccessParams) (GetMediaAccessRow, error) {
+	row := q.db.QueryRowContext(ctx, getMediaAccess, arg.MediaID, arg.ViewerID)
+	var i GetMediaAccessRow
+	err := row.Scan(&i.Visible, &i.Public)
+	return i, err
+}
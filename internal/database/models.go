@@ -12,20 +12,203 @@ import (
 )
 
 type Chirp struct {
-	ID        uuid.UUID
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Body                 string
+	UserID               uuid.NullUUID
+	InReplyTo            uuid.NullUUID
+	QuoteOf              uuid.NullUUID
+	SearchVector         interface{}
+	DeletedAt            sql.NullTime
+	PinnedAt             sql.NullTime
+	Visibility           string
+	ExpiresAt            sql.NullTime
+	ContentWarning       sql.NullString
+	Sensitive            bool
+	SensitiveByModerator bool
+}
+
+type ChirpBookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
+}
+
+type ChirpDraft struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	UserID         uuid.UUID
+	Body           string
+	InReplyTo      uuid.NullUUID
+	QuoteOf        uuid.NullUUID
+	PublishAt      sql.NullTime
+	LastError      sql.NullString
+	MediaIds       []uuid.UUID
+	Visibility     string
+	ContentWarning sql.NullString
+	Sensitive      bool
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpLike struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpMedium struct {
+	ChirpID  uuid.UUID
+	MediaID  uuid.UUID
+	Position int32
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	StartOffset int32
+	EndOffset   int32
+}
+
+type ChirpRevision struct {
+	ID        uuid.UUID
+	ChirpID   uuid.UUID
+	Revision  int32
 	Body      string
-	UserID    uuid.NullUUID
+	CreatedAt time.Time
 }
 
-type RefreshToken struct {
-	Token     string
+type ChirpTombstone struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	DeletedAt time.Time
+	InReplyTo uuid.NullUUID
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Tag       string
+}
+
+type Medium struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UserID               uuid.UUID
+	ContentType          string
+	BlobKey              string
+	SizeBytes            int32
+	Width                int32
+	Height               int32
+	ThumbnailKey         string
+	ThumbnailContentType string
+}
+
+type MfaChallenge struct {
+	TokenHash string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Attempts  int32
+}
+
+type MfaRecoveryCode struct {
+	UserID    uuid.UUID
+	CodeHash  string
+	CreatedAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type Notification struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	UserID        uuid.UUID
+	Kind          string
+	ChirpID       uuid.NullUUID
+	GroupKey      string
+	ActorCount    int32
+	LatestActorID uuid.NullUUID
+	ReadAt        sql.NullTime
+}
+
+type NotificationActor struct {
+	NotificationID uuid.UUID
+	ActorID        uuid.UUID
+	CreatedAt      time.Time
+}
+
+type PasswordResetToken struct {
+	TokenHash string
+	UserID    uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.NullUUID
 	ExpiresAt time.Time
-	RevokedAt sql.NullTime
+	UsedAt    sql.NullTime
+}
+
+type Poll struct {
+	ID        uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	ClosesAt  time.Time
+	ClosedAt  sql.NullTime
+}
+
+type PollOption struct {
+	ID         uuid.UUID
+	PollID     uuid.UUID
+	Position   int32
+	Label      string
+	FinalVotes sql.NullInt32
+}
+
+type PollVote struct {
+	PollID    uuid.UUID
+	UserID    uuid.UUID
+	OptionID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type Rechirp struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type RefreshToken struct {
+	Token      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.NullUUID
+	ExpiresAt  time.Time
+	RevokedAt  sql.NullTime
+	FamilyID   uuid.UUID
+	ReplacedBy sql.NullString
+	UserAgent  string
+	IpAddress  string
+	LastUsedAt sql.NullTime
+}
+
+type SigningKey struct {
+	Kid                 string
+	Algorithm           string
+	EncryptedPrivateKey []byte
+	CreatedAt           time.Time
+	NotBefore           time.Time
+	RetiresAt           sql.NullTime
 }
 
 type User struct {
@@ -35,4 +218,12 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	Handle         sql.NullString
+	ShowSensitive  bool
+	IsModerator    bool
+	TotpSecret     []byte
+	TotpEnabledAt  sql.NullTime
+	TotpLastStep   sql.NullInt64
+	MfaAttempts    int32
+	MfaLockedUntil sql.NullTime
 }
@@ -0,0 +1,179 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: notifications.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const addNotificationActor = `-- name: AddNotificationActor :exec
+INSERT INTO notification_actors (notification_id, actor_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (notification_id, actor_id) DO NOTHING
+`
+
+type AddNotificationActorParams struct {
+	NotificationID uuid.UUID
+	ActorID        uuid.UUID
+}
+
+func (q *Queries) AddNotificationActor(ctx context.Context, arg AddNotificationActorParams) error {
+	_, err := q.db.ExecContext(ctx, addNotificationActor, arg.NotificationID, arg.ActorID)
+	return err
+}
+
+const getNotifications = `-- name: GetNotifications :many
+SELECT id, created_at, updated_at, user_id, kind, chirp_id, group_key, actor_count, latest_actor_id, read_at
+FROM notifications
+WHERE user_id = $1
+    AND (NOT $2::boolean OR read_at IS NULL)
+    AND (
+        $3::timestamp IS NULL
+        OR (updated_at, id) < ($3::timestamp, $4::uuid)
+    )
+ORDER BY updated_at DESC, id DESC
+LIMIT $5
+`
+
+type GetNotificationsParams struct {
+	UserID          uuid.UUID
+	UnreadOnly      bool
+	CursorUpdatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) GetNotifications(ctx context.Context, arg GetNotificationsParams) ([]Notification, error) {
+	rows, err := q.db.QueryContext(ctx, getNotifications,
+		arg.UserID,
+		arg.UnreadOnly,
+		arg.CursorUpdatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Notification
+	for rows.Next() {
+		var i Notification
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Kind,
+			&i.ChirpID,
+			&i.GroupKey,
+			&i.ActorCount,
+			&i.LatestActorID,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markAllNotificationsRead = `-- name: MarkAllNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL
+`
+
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markAllNotificationsRead, userID)
+	return err
+}
+
+const markNotificationsRead = `-- name: MarkNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1
+    AND read_at IS NULL
+    AND id = ANY($2::uuid[])
+`
+
+type MarkNotificationsReadParams struct {
+	UserID uuid.UUID
+	Ids    []uuid.UUID
+}
+
+func (q *Queries) MarkNotificationsRead(ctx context.Context, arg MarkNotificationsReadParams) error {
+	_, err := q.db.ExecContext(ctx, markNotificationsRead, arg.UserID, pq.Array(arg.Ids))
+	return err
+}
+
+const refreshNotificationActorCount = `-- name: RefreshNotificationActorCount :exec
+UPDATE notifications
+SET actor_count = (
+    SELECT COUNT(*)
+    FROM notification_actors
+    WHERE notification_actors.notification_id = notifications.id
+)
+WHERE id = $1
+`
+
+func (q *Queries) RefreshNotificationActorCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, refreshNotificationActorCount, id)
+	return err
+}
+
+const upsertNotification = `-- name: UpsertNotification :one
+INSERT INTO notifications (id, created_at, updated_at, user_id, kind, chirp_id, group_key, actor_count, latest_actor_id, read_at)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    0,
+    $5,
+    NULL
+)
+ON CONFLICT (user_id, group_key) WHERE read_at IS NULL
+DO UPDATE SET updated_at = NOW(), latest_actor_id = COALESCE(EXCLUDED.latest_actor_id, notifications.latest_actor_id)
+RETURNING id
+`
+
+type UpsertNotificationParams struct {
+	UserID        uuid.UUID
+	Kind          string
+	ChirpID       uuid.NullUUID
+	GroupKey      string
+	LatestActorID uuid.NullUUID
+}
+
+func (q *Queries) UpsertNotification(ctx context.Context, arg UpsertNotificationParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, upsertNotification,
+		arg.UserID,
+		arg.Kind,
+		arg.ChirpID,
+		arg.GroupKey,
+		arg.LatestActorID,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
@@ -0,0 +1,131 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: pinned_chirps.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const countPinnedChirps = `-- name: CountPinnedChirps :one
+SELECT COUNT(*)
+FROM chirps
+WHERE user_id = $1 AND pinned_at IS NOT NULL AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+`
+
+func (q *Queries) CountPinnedChirps(ctx context.Context, userID uuid.NullUUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPinnedChirps, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getPinnedChirps = `-- name: GetPinnedChirps :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, quote_of, search_vector, deleted_at, pinned_at, visibility, expires_at, content_warning, sensitive, sensitive_by_moderator
+FROM chirps
+WHERE user_id = $1
+    AND pinned_at IS NOT NULL
+    AND deleted_at IS NULL
+    AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(id, user_id, visibility, $2::uuid)
+    AND ($3::boolean OR NOT sensitive)
+ORDER BY pinned_at DESC
+`
+
+type GetPinnedChirpsParams struct {
+	UserID           uuid.NullUUID
+	ViewerID         uuid.NullUUID
+	IncludeSensitive bool
+}
+
+func (q *Queries) GetPinnedChirps(ctx context.Context, arg GetPinnedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getPinnedChirps, arg.UserID, arg.ViewerID, arg.IncludeSensitive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.QuoteOf,
+			&i.SearchVector,
+			&i.DeletedAt,
+			&i.PinnedAt,
+			&i.Visibility,
+			&i.ExpiresAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveByModerator,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockUserPins = `-- name: LockUserPins :exec
+SELECT id
+FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+// Taken before counting a user's pins, so two requests can't both see room
+// for one more.
+func (q *Queries) LockUserPins(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, lockUserPins, id)
+	return err
+}
+
+const pinChirp = `-- name: PinChirp :execrows
+UPDATE chirps
+SET pinned_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND pinned_at IS NULL
+`
+
+type PinChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.NullUUID
+}
+
+func (q *Queries) PinChirp(ctx context.Context, arg PinChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, pinChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unpinChirp = `-- name: UnpinChirp :exec
+UPDATE chirps
+SET pinned_at = NULL
+WHERE id = $1 AND user_id = $2
+`
+
+type UnpinChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.NullUUID
+}
+
+func (q *Queries) UnpinChirp(ctx context.Context, arg UnpinChirpParams) error {
+	_, err := q.db.ExecContext(ctx, unpinChirp, arg.ID, arg.UserID)
+	return err
+}
@@ -0,0 +1,317 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: polls.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const claimDuePoll = `-- name: ClaimDuePoll :one
+SELECT polls.id, polls.chirp_id, chirps.user_id AS author_id, chirps.deleted_at AS chirp_deleted_at
+FROM polls
+JOIN chirps ON chirps.id = polls.chirp_id
+WHERE polls.closed_at IS NULL
+    AND polls.closes_at <= $1::timestamp
+ORDER BY polls.closes_at ASC
+LIMIT 1
+FOR UPDATE OF polls SKIP LOCKED
+`
+
+type ClaimDuePollRow struct {
+	ID             uuid.UUID
+	ChirpID        uuid.UUID
+	AuthorID       uuid.NullUUID
+	ChirpDeletedAt sql.NullTime
+}
+
+func (q *Queries) ClaimDuePoll(ctx context.Context, now time.Time) (ClaimDuePollRow, error) {
+	row := q.db.QueryRowContext(ctx, claimDuePoll, now)
+	var i ClaimDuePollRow
+	err := row.Scan(
+		&i.ID,
+		&i.ChirpID,
+		&i.AuthorID,
+		&i.ChirpDeletedAt,
+	)
+	return i, err
+}
+
+const closePoll = `-- name: ClosePoll :exec
+UPDATE polls
+SET closed_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) ClosePoll(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, closePoll, id)
+	return err
+}
+
+const countPollVotes = `-- name: CountPollVotes :many
+SELECT option_id, COUNT(*) AS votes
+FROM poll_votes
+WHERE poll_id = ANY($1::uuid[])
+GROUP BY option_id
+`
+
+type CountPollVotesRow struct {
+	OptionID uuid.UUID
+	Votes    int64
+}
+
+func (q *Queries) CountPollVotes(ctx context.Context, pollIds []uuid.UUID) ([]CountPollVotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, countPollVotes, pq.Array(pollIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountPollVotesRow
+	for rows.Next() {
+		var i CountPollVotesRow
+		if err := rows.Scan(&i.OptionID, &i.Votes); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createPoll = `-- name: CreatePoll :one
+INSERT INTO polls (id, chirp_id, created_at, closes_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    NOW(),
+    $2
+)
+RETURNING id, chirp_id, created_at, closes_at, closed_at
+`
+
+type CreatePollParams struct {
+	ChirpID  uuid.UUID
+	ClosesAt time.Time
+}
+
+func (q *Queries) CreatePoll(ctx context.Context, arg CreatePollParams) (Poll, error) {
+	row := q.db.QueryRowContext(ctx, createPoll, arg.ChirpID, arg.ClosesAt)
+	var i Poll
+	err := row.Scan(
+		&i.ID,
+		&i.ChirpID,
+		&i.CreatedAt,
+		&i.ClosesAt,
+		&i.ClosedAt,
+	)
+	return i, err
+}
+
+const createPollOption = `-- name: CreatePollOption :exec
+INSERT INTO poll_options (id, poll_id, position, label)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3
+)
+`
+
+type CreatePollOptionParams struct {
+	PollID   uuid.UUID
+	Position int32
+	Label    string
+}
+
+func (q *Queries) CreatePollOption(ctx context.Context, arg CreatePollOptionParams) error {
+	_, err := q.db.ExecContext(ctx, createPollOption, arg.PollID, arg.Position, arg.Label)
+	return err
+}
+
+const finalizePollOptions = `-- name: FinalizePollOptions :exec
+UPDATE poll_options
+SET final_votes = (
+    SELECT COUNT(*)
+    FROM poll_votes
+    WHERE poll_votes.option_id = poll_options.id
+)
+WHERE poll_options.poll_id = $1
+`
+
+func (q *Queries) FinalizePollOptions(ctx context.Context, pollID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, finalizePollOptions, pollID)
+	return err
+}
+
+const getPollByChirp = `-- name: GetPollByChirp :one
+SELECT id, chirp_id, created_at, closes_at, closed_at
+FROM polls
+WHERE chirp_id = $1
+`
+
+func (q *Queries) GetPollByChirp(ctx context.Context, chirpID uuid.UUID) (Poll, error) {
+	row := q.db.QueryRowContext(ctx, getPollByChirp, chirpID)
+	var i Poll
+	err := row.Scan(
+		&i.ID,
+		&i.ChirpID,
+		&i.CreatedAt,
+		&i.ClosesAt,
+		&i.ClosedAt,
+	)
+	return i, err
+}
+
+const getPollOptions = `-- name: GetPollOptions :many
+SELECT id, poll_id, position, label, final_votes
+FROM poll_options
+WHERE poll_id = ANY($1::uuid[])
+ORDER BY poll_id, position
+`
+
+func (q *Queries) GetPollOptions(ctx context.Context, pollIds []uuid.UUID) ([]PollOption, error) {
+	rows, err := q.db.QueryContext(ctx, getPollOptions, pq.Array(pollIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PollOption
+	for rows.Next() {
+		var i PollOption
+		if err := rows.Scan(
+			&i.ID,
+			&i.PollID,
+			&i.Position,
+			&i.Label,
+			&i.FinalVotes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPollsByChirpIDs = `-- name: GetPollsByChirpIDs :many
+SELECT id, chirp_id, created_at, closes_at, closed_at
+FROM polls
+WHERE chirp_id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetPollsByChirpIDs(ctx context.Context, chirpIds []uuid.UUID) ([]Poll, error) {
+	rows, err := q.db.QueryContext(ctx, getPollsByChirpIDs, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Poll
+	for rows.Next() {
+		var i Poll
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChirpID,
+			&i.CreatedAt,
+			&i.ClosesAt,
+			&i.ClosedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserPollVotes = `-- name: GetUserPollVotes :many
+SELECT poll_id, option_id
+FROM poll_votes
+WHERE user_id = $1 AND poll_id = ANY($2::uuid[])
+`
+
+type GetUserPollVotesParams struct {
+	UserID  uuid.UUID
+	PollIds []uuid.UUID
+}
+
+type GetUserPollVotesRow struct {
+	PollID   uuid.UUID
+	OptionID uuid.UUID
+}
+
+func (q *Queries) GetUserPollVotes(ctx context.Context, arg GetUserPollVotesParams) ([]GetUserPollVotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserPollVotes, arg.UserID, pq.Array(arg.PollIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserPollVotesRow
+	for rows.Next() {
+		var i GetUserPollVotesRow
+		if err := rows.Scan(&i.PollID, &i.OptionID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const votePoll = `-- name: VotePoll :execrows
+INSERT INTO poll_votes (poll_id, user_id, option_id, created_at)
+SELECT polls.id, $1, $2, NOW()
+FROM polls
+WHERE polls.id = $3
+    AND polls.closed_at IS NULL
+    AND polls.closes_at > $4::timestamp
+FOR SHARE
+`
+
+type VotePollParams struct {
+	UserID   uuid.UUID
+	OptionID uuid.UUID
+	PollID   uuid.UUID
+	Now      time.Time
+}
+
+// Inserts nothing once the poll has closed. FOR SHARE makes a vote wait for
+// a closing job that's finalizing the same poll, then see it as closed.
+func (q *Queries) VotePoll(ctx context.Context, arg VotePollParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, votePoll,
+		arg.UserID,
+		arg.OptionID,
+		arg.PollID,
+		arg.Now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
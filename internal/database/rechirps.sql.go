@@ -0,0 +1,84 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: rechirps.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const countRechirps = `-- name: CountRechirps :many
+SELECT chirp_id, COUNT(*) AS rechirp_count
+FROM rechirps
+WHERE chirp_id = ANY($1::uuid[])
+GROUP BY chirp_id
+`
+
+type CountRechirpsRow struct {
+	ChirpID      uuid.UUID
+	RechirpCount int64
+}
+
+func (q *Queries) CountRechirps(ctx context.Context, chirpIds []uuid.UUID) ([]CountRechirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countRechirps, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRechirpsRow
+	for rows.Next() {
+		var i CountRechirpsRow
+		if err := rows.Scan(&i.ChirpID, &i.RechirpCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rechirp = `-- name: Rechirp :exec
+INSERT INTO rechirps (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING
+`
+
+type RechirpParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) Rechirp(ctx context.Context, arg RechirpParams) error {
+	_, err := q.db.ExecContext(ctx, rechirp, arg.UserID, arg.ChirpID)
+	return err
+}
+
+const undoRechirp = `-- name: UndoRechirp :exec
+DELETE
+FROM rechirps
+WHERE user_id = $1 AND chirp_id = $2
+`
+
+type UndoRechirpParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) UndoRechirp(ctx context.Context, arg UndoRechirpParams) error {
+	_, err := q.db.ExecContext(ctx, undoRechirp, arg.UserID, arg.ChirpID)
+	return err
+}
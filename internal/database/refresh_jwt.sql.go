@@ -7,22 +7,101 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
-const refreshJWT = `-- name: RefreshJWT :exec
+const createRotatedRefreshToken = `-- name: CreateRotatedRefreshToken :exec
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, user_agent, ip_address, last_used_at)
+VALUES (
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    $3,
+    NULL,
+    $4,
+    $5,
+    $6,
+    NOW()
+)
+`
+
+type CreateRotatedRefreshTokenParams struct {
+	Token     string
+	UserID    uuid.NullUUID
+	ExpiresAt time.Time
+	FamilyID  uuid.UUID
+	UserAgent string
+	IpAddress string
+}
+
+func (q *Queries) CreateRotatedRefreshToken(ctx context.Context, arg CreateRotatedRefreshTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createRotatedRefreshToken,
+		arg.Token,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.FamilyID,
+		arg.UserAgent,
+		arg.IpAddress,
+	)
+	return err
+}
+
+const lockRefreshToken = `-- name: LockRefreshToken :one
+SELECT token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, replaced_by, user_agent, ip_address, last_used_at
+FROM refresh_tokens
+WHERE token = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, lockRefreshToken, token)
+	var i RefreshToken
+	err := row.Scan(
+		&i.Token,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const markRefreshTokenRotated = `-- name: MarkRefreshTokenRotated :exec
 UPDATE refresh_tokens
-SET token = $1
-WHERE user_id = $2
+SET replaced_by = $2, revoked_at = NOW(), updated_at = NOW()
+WHERE token = $1
 `
 
-type RefreshJWTParams struct {
-	Token  string
-	UserID uuid.NullUUID
+type MarkRefreshTokenRotatedParams struct {
+	Token      string
+	ReplacedBy sql.NullString
 }
 
-func (q *Queries) RefreshJWT(ctx context.Context, arg RefreshJWTParams) error {
-	_, err := q.db.ExecContext(ctx, refreshJWT, arg.Token, arg.UserID)
+func (q *Queries) MarkRefreshTokenRotated(ctx context.Context, arg MarkRefreshTokenRotatedParams) error {
+	_, err := q.db.ExecContext(ctx, markRefreshTokenRotated, arg.Token, arg.ReplacedBy)
 	return err
 }
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
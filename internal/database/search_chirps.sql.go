@@ -0,0 +1,108 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: search_chirps.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.quote_of, chirps.search_vector, chirps.deleted_at, chirps.pinned_at, chirps.visibility, chirps.expires_at, chirps.content_warning, chirps.sensitive, chirps.sensitive_by_moderator, ts_rank(chirps.search_vector, websearch_to_tsquery('english', $1))::real AS rank
+FROM chirps
+WHERE chirps.search_vector @@ websearch_to_tsquery('english', $1)
+    AND chirps.deleted_at IS NULL
+    AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $2::uuid)
+    AND ($3::uuid IS NULL OR chirps.user_id = $3::uuid)
+    AND ($4::timestamp IS NULL OR chirps.created_at >= $4::timestamp)
+    AND ($5::timestamp IS NULL OR chirps.created_at < $5::timestamp)
+    AND (
+        $6::uuid IS NULL
+        OR ($7 = 'relevance' AND (ts_rank(chirps.search_vector, websearch_to_tsquery('english', $1))::real, chirps.id) < ($8::real, $6::uuid))
+        OR ($7 = 'desc' AND (chirps.created_at, chirps.id) < ($9::timestamp, $6::uuid))
+        OR ($7 = 'asc' AND (chirps.created_at, chirps.id) > ($9::timestamp, $6::uuid))
+    )
+ORDER BY
+    CASE WHEN $7 = 'relevance' THEN ts_rank(chirps.search_vector, websearch_to_tsquery('english', $1))::real END DESC,
+    CASE WHEN $7 = 'relevance' THEN chirps.id END DESC,
+    CASE WHEN $7 = 'desc' THEN chirps.created_at END DESC,
+    CASE WHEN $7 = 'desc' THEN chirps.id END DESC,
+    CASE WHEN $7 = 'asc' THEN chirps.created_at END ASC,
+    CASE WHEN $7 = 'asc' THEN chirps.id END ASC
+LIMIT $10
+`
+
+type SearchChirpsParams struct {
+	Query           string
+	ViewerID        uuid.NullUUID
+	AuthorID        uuid.NullUUID
+	Since           sql.NullTime
+	Until           sql.NullTime
+	CursorID        uuid.NullUUID
+	SortOrder       interface{}
+	CursorRank      sql.NullFloat64
+	CursorCreatedAt sql.NullTime
+	PageLimit       int32
+}
+
+type SearchChirpsRow struct {
+	Chirp Chirp
+	Rank  float32
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.Since,
+		arg.Until,
+		arg.CursorID,
+		arg.SortOrder,
+		arg.CursorRank,
+		arg.CursorCreatedAt,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.QuoteOf,
+			&i.Chirp.SearchVector,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.PinnedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ExpiresAt,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.SensitiveByModerator,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,73 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: sensitivity.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const updateChirpSensitivity = `-- name: UpdateChirpSensitivity :one
+UPDATE chirps
+SET content_warning = $1,
+    sensitive = $2,
+    sensitive_by_moderator = $3
+WHERE id = $4 AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, quote_of, search_vector, deleted_at, pinned_at, visibility, expires_at, content_warning, sensitive, sensitive_by_moderator
+`
+
+type UpdateChirpSensitivityParams struct {
+	ContentWarning       sql.NullString
+	Sensitive            bool
+	SensitiveByModerator bool
+	ID                   uuid.UUID
+}
+
+func (q *Queries) UpdateChirpSensitivity(ctx context.Context, arg UpdateChirpSensitivityParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpSensitivity,
+		arg.ContentWarning,
+		arg.Sensitive,
+		arg.SensitiveByModerator,
+		arg.ID,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.QuoteOf,
+		&i.SearchVector,
+		&i.DeletedAt,
+		&i.PinnedAt,
+		&i.Visibility,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveByModerator,
+	)
+	return i, err
+}
+
+const updateShowSensitive = `-- name: UpdateShowSensitive :exec
+UPDATE users
+SET show_sensitive = $1, updated_at = NOW()
+WHERE id = $2
+`
+
+type UpdateShowSensitiveParams struct {
+	ShowSensitive bool
+	ID            uuid.UUID
+}
+
+func (q *Queries) UpdateShowSensitive(ctx context.Context, arg UpdateShowSensitiveParams) error {
+	_, err := q.db.ExecContext(ctx, updateShowSensitive, arg.ShowSensitive, arg.ID)
+	return err
+}
@@ -0,0 +1,108 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: signing_keys.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createSigningKey = `-- name: CreateSigningKey :exec
+INSERT INTO signing_keys (kid, algorithm, encrypted_private_key, created_at, not_before)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateSigningKeyParams struct {
+	Kid                 string
+	Algorithm           string
+	EncryptedPrivateKey []byte
+	CreatedAt           time.Time
+	NotBefore           time.Time
+}
+
+func (q *Queries) CreateSigningKey(ctx context.Context, arg CreateSigningKeyParams) error {
+	_, err := q.db.ExecContext(ctx, createSigningKey,
+		arg.Kid,
+		arg.Algorithm,
+		arg.EncryptedPrivateKey,
+		arg.CreatedAt,
+		arg.NotBefore,
+	)
+	return err
+}
+
+const deleteRetiredSigningKeys = `-- name: DeleteRetiredSigningKeys :exec
+DELETE FROM signing_keys
+WHERE retires_at <= $1::timestamp
+`
+
+func (q *Queries) DeleteRetiredSigningKeys(ctx context.Context, now time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteRetiredSigningKeys, now)
+	return err
+}
+
+const getSigningKeys = `-- name: GetSigningKeys :many
+SELECT kid, algorithm, encrypted_private_key, created_at, not_before, retires_at
+FROM signing_keys
+WHERE retires_at IS NULL OR retires_at > $1::timestamp
+ORDER BY not_before ASC
+`
+
+func (q *Queries) GetSigningKeys(ctx context.Context, now time.Time) ([]SigningKey, error) {
+	rows, err := q.db.QueryContext(ctx, getSigningKeys, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SigningKey
+	for rows.Next() {
+		var i SigningKey
+		if err := rows.Scan(
+			&i.Kid,
+			&i.Algorithm,
+			&i.EncryptedPrivateKey,
+			&i.CreatedAt,
+			&i.NotBefore,
+			&i.RetiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockSigningKeys = `-- name: LockSigningKeys :exec
+SELECT pg_advisory_xact_lock(hashtext('signing_keys'))
+`
+
+func (q *Queries) LockSigningKeys(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockSigningKeys)
+	return err
+}
+
+const retireSigningKeys = `-- name: RetireSigningKeys :exec
+UPDATE signing_keys
+SET retires_at = $1
+WHERE retires_at IS NULL AND kid <> $2
+`
+
+type RetireSigningKeysParams struct {
+	RetiresAt sql.NullTime
+	Kid       string
+}
+
+func (q *Queries) RetireSigningKeys(ctx context.Context, arg RetireSigningKeysParams) error {
+	_, err := q.db.ExecContext(ctx, retireSigningKeys, arg.RetiresAt, arg.Kid)
+	return err
+}
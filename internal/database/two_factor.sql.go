@@ -0,0 +1,231 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: two_factor.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const claimMFAChallengeAttempt = `-- name: ClaimMFAChallengeAttempt :one
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE token_hash = $1
+    AND expires_at > LOCALTIMESTAMP
+    AND attempts < $2::int
+RETURNING user_id
+`
+
+type ClaimMFAChallengeAttemptParams struct {
+	TokenHash   string
+	MaxAttempts int32
+}
+
+func (q *Queries) ClaimMFAChallengeAttempt(ctx context.Context, arg ClaimMFAChallengeAttemptParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, claimMFAChallengeAttempt, arg.TokenHash, arg.MaxAttempts)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const claimUserMFAAttempt = `-- name: ClaimUserMFAAttempt :execrows
+UPDATE users
+SET mfa_attempts = CASE
+        WHEN mfa_attempts + 1 >= $1::int THEN 0
+        ELSE mfa_attempts + 1
+    END,
+    mfa_locked_until = CASE
+        WHEN mfa_attempts + 1 >= $1::int
+            THEN LOCALTIMESTAMP + make_interval(secs => $2::float8)
+        ELSE NULL
+    END
+WHERE id = $3
+    AND (mfa_locked_until IS NULL OR mfa_locked_until <= LOCALTIMESTAMP)
+`
+
+type ClaimUserMFAAttemptParams struct {
+	MaxAttempts    int32
+	LockoutSeconds float64
+	ID             uuid.UUID
+}
+
+func (q *Queries) ClaimUserMFAAttempt(ctx context.Context, arg ClaimUserMFAAttemptParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, claimUserMFAAttempt, arg.MaxAttempts, arg.LockoutSeconds, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createMFAChallenge = `-- name: CreateMFAChallenge :exec
+INSERT INTO mfa_challenges (token_hash, user_id, created_at, expires_at)
+VALUES (
+    $1,
+    $2,
+    NOW(),
+    NOW() + make_interval(secs => $3::float8)
+)
+`
+
+type CreateMFAChallengeParams struct {
+	TokenHash  string
+	UserID     uuid.UUID
+	TtlSeconds float64
+}
+
+func (q *Queries) CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) error {
+	_, err := q.db.ExecContext(ctx, createMFAChallenge, arg.TokenHash, arg.UserID, arg.TtlSeconds)
+	return err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at)
+VALUES ($1, $2, NOW())
+`
+
+type CreateRecoveryCodeParams struct {
+	UserID   uuid.UUID
+	CodeHash string
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.ExecContext(ctx, createRecoveryCode, arg.UserID, arg.CodeHash)
+	return err
+}
+
+const deleteExpiredMFAChallenges = `-- name: DeleteExpiredMFAChallenges :exec
+DELETE FROM mfa_challenges
+WHERE expires_at <= LOCALTIMESTAMP
+`
+
+func (q *Queries) DeleteExpiredMFAChallenges(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredMFAChallenges)
+	return err
+}
+
+const deleteMFAChallenge = `-- name: DeleteMFAChallenge :execrows
+DELETE FROM mfa_challenges
+WHERE token_hash = $1
+`
+
+func (q *Queries) DeleteMFAChallenge(ctx context.Context, tokenHash string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteMFAChallenge, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM mfa_recovery_codes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRecoveryCodes, userID)
+	return err
+}
+
+const disableTOTP = `-- name: DisableTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL,
+    mfa_attempts = 0, mfa_locked_until = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, disableTOTP, id)
+	return err
+}
+
+const enableTOTP = `-- name: EnableTOTP :execrows
+UPDATE users
+SET totp_enabled_at = NOW(), totp_last_step = $1::bigint, updated_at = NOW()
+WHERE id = $2 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL
+`
+
+type EnableTOTPParams struct {
+	Step int64
+	ID   uuid.UUID
+}
+
+func (q *Queries) EnableTOTP(ctx context.Context, arg EnableTOTPParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enableTOTP, arg.Step, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const resetUserMFAAttempts = `-- name: ResetUserMFAAttempts :exec
+UPDATE users
+SET mfa_attempts = 0, mfa_locked_until = NULL
+WHERE id = $1
+`
+
+func (q *Queries) ResetUserMFAAttempts(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, resetUserMFAAttempts, id)
+	return err
+}
+
+const startTOTPSetup = `-- name: StartTOTPSetup :execrows
+UPDATE users
+SET totp_secret = $2, totp_last_step = NULL, updated_at = NOW()
+WHERE id = $1 AND totp_enabled_at IS NULL
+`
+
+type StartTOTPSetupParams struct {
+	ID         uuid.UUID
+	TotpSecret []byte
+}
+
+func (q *Queries) StartTOTPSetup(ctx context.Context, arg StartTOTPSetupParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, startTOTPSetup, arg.ID, arg.TotpSecret)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE mfa_recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   uuid.UUID
+	CodeHash string
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $1::bigint
+WHERE id = $2
+    AND (totp_last_step IS NULL OR totp_last_step < $1::bigint)
+`
+
+type UseTOTPStepParams struct {
+	Step int64
+	ID   uuid.UUID
+}
+
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTOTPStep, arg.Step, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,77 @@
+package hashtag
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxTagLength = 100
+
+// Extract returns the normalized, de-duplicated hashtags in body, in the
+// order they first appear. A tag is a '#' that isn't glued to the end of a
+// word, followed by letters, marks, digits or underscores. Tags made only of
+// digits (#1) are ignored.
+func Extract(body string) []string {
+	var tags []string
+	seen := map[string]struct{}{}
+
+	prev := ' '
+	for i := 0; i < len(body); {
+		r, size := utf8.DecodeRuneInString(body[i:])
+		if r != '#' || isTagRune(prev) {
+			prev = r
+			i += size
+			continue
+		}
+
+		prev = r
+		j := i + size
+		for j < len(body) {
+			next, nextSize := utf8.DecodeRuneInString(body[j:])
+			if !isTagRune(next) {
+				break
+			}
+			prev = next
+			j += nextSize
+		}
+
+		tag := Normalize(body[i+size : j])
+		if isValid(tag) {
+			if _, ok := seen[tag]; !ok {
+				seen[tag] = struct{}{}
+				tags = append(tags, tag)
+			}
+		}
+
+		i = j
+	}
+
+	return tags
+}
+
+// Normalize puts a tag into the form it is stored and looked up in: any
+// leading '#' removed, NFC-composed and lower-cased, so "#Café" written with a
+// combining accent matches "#café".
+func Normalize(tag string) string {
+	tag = strings.TrimPrefix(tag, "#")
+	return strings.ToLower(norm.NFC.String(tag))
+}
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func isValid(tag string) bool {
+	if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+		return false
+	}
+	for _, r := range tag {
+		if !unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package hashtag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"no tags here", nil},
+		{"#Go is #fun, #go!", []string{"go", "fun"}},
+		{"email me at a#b or #", nil},
+		{"#1 fan of #2024goals", []string{"2024goals"}},
+		{"日本語 #東京 and #Ünïcödé", []string{"東京", "ünïcödé"}},
+		{"#snake_case#second", []string{"snake_case"}},
+	}
+
+	for _, tc := range tests {
+		got := Extract(tc.body)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("Extract(%q): expected %v but got %v", tc.body, tc.want, got)
+		}
+	}
+}
+
+func TestNormalizeCombiningMarks(t *testing.T) {
+	composed := Normalize("#Café")
+	decomposed := Normalize("#Café")
+
+	if composed != decomposed {
+		t.Fatalf("Expected %q and %q to normalize to the same tag", composed, decomposed)
+	}
+	if composed != "café" {
+		t.Fatalf("Expected café but got: %s", composed)
+	}
+}
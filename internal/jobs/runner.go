@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+}
+
+// Runner runs background jobs on fixed intervals until it's stopped. A job
+// that fails is logged and tried again on its next tick.
+type Runner struct {
+	jobs   []job
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRunner() *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{ctx: ctx, cancel: cancel}
+}
+
+// Every registers run to be called once per interval. It must be called
+// before Start.
+func (r *Runner) Every(name string, interval time.Duration, run func(ctx context.Context) error) {
+	r.jobs = append(r.jobs, job{name: name, interval: interval, run: run})
+}
+
+// Start launches one goroutine per job. Each job also runs once right away,
+// so work that piled up while the server was down isn't left waiting.
+func (r *Runner) Start() {
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			ticker := time.NewTicker(j.interval)
+			defer ticker.Stop()
+			for {
+				if err := j.run(r.ctx); err != nil && r.ctx.Err() == nil {
+					log.Printf("Error running %s: %v", j.name, err)
+				}
+				select {
+				case <-r.ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+}
+
+// Stop cancels the context passed to running jobs and waits for them to
+// return.
+func (r *Runner) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunnerRunsJobsUntilStopped(t *testing.T) {
+	r := NewRunner()
+	var runs atomic.Int32
+	r.Every("counter", 10*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+	r.Start()
+
+	time.Sleep(55 * time.Millisecond)
+	r.Stop()
+	stopped := runs.Load()
+	if stopped < 3 {
+		t.Fatalf("Expected at least 3 runs but got %d", stopped)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if runs.Load() != stopped {
+		t.Fatalf("Expected no runs after Stop")
+	}
+}
+
+func TestRunnerKeepsGoingAfterErrors(t *testing.T) {
+	r := NewRunner()
+	var runs atomic.Int32
+	r.Every("failing", 10*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return errors.New("boom")
+	})
+	r.Start()
+	time.Sleep(35 * time.Millisecond)
+	r.Stop()
+
+	if runs.Load() < 2 {
+		t.Fatalf("Expected the job to be retried but it ran %d times", runs.Load())
+	}
+}
+
+func TestStopCancelsRunningJob(t *testing.T) {
+	r := NewRunner()
+	started := make(chan struct{})
+	r.Every("blocking", time.Hour, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	r.Start()
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		r.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Stop to cancel the running job")
+	}
+}
@@ -0,0 +1,87 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	data, err := format("chirpy@example.com", Message{
+		To:      "walt@example.com",
+		Subject: "Reset your password",
+		Body:    "line one\nline two",
+	}, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("Error formatting: %v", err)
+	}
+
+	s := string(data)
+	for _, want := range []string{
+		"From: chirpy@example.com\r\n",
+		"To: walt@example.com\r\n",
+		"Subject: Reset your password\r\n",
+		"\r\n\r\nline one\r\nline two",
+	} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("Expected %q in message:\n%s", want, s)
+		}
+	}
+}
+
+func TestFormatRejectsHeaderInjection(t *testing.T) {
+	_, err := format("chirpy@example.com", Message{
+		To:      "walt@example.com\r\nBcc: everyone@example.com",
+		Subject: "hi",
+	}, time.Now())
+	if err == nil {
+		t.Fatalf("Expected a line break in a header to be refused")
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	m := NewMemoryMailer()
+	if err := m.Send(context.Background(), Message{To: "a@example.com", Subject: "one"}); err != nil {
+		t.Fatalf("Error sending: %v", err)
+	}
+	if err := m.Send(context.Background(), Message{To: "b@example.com", Subject: "two"}); err != nil {
+		t.Fatalf("Error sending: %v", err)
+	}
+
+	sent := m.Sent()
+	if len(sent) != 2 || sent[0].Subject != "one" || sent[1].Subject != "two" {
+		t.Fatalf("Expected both messages in order but got %+v", sent)
+	}
+}
+
+func TestFileOutbox(t *testing.T) {
+	dir := t.TempDir()
+	o, err := NewFileOutbox(dir, "chirpy@example.com")
+	if err != nil {
+		t.Fatalf("Error making outbox: %v", err)
+	}
+
+	for _, subject := range []string{"one", "two"} {
+		if err := o.Send(context.Background(), Message{To: "walt@example.com", Subject: subject, Body: "hello"}); err != nil {
+			t.Fatalf("Error sending: %v", err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatalf("Error listing outbox: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 messages in the outbox but found %d", len(files))
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("Error reading message: %v", err)
+	}
+	if !strings.Contains(string(data), "To: walt@example.com") {
+		t.Fatalf("Unexpected message:\n%s", data)
+	}
+}
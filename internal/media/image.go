@@ -0,0 +1,381 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	TypeJPEG = "image/jpeg"
+	TypePNG  = "image/png"
+	TypeGIF  = "image/gif"
+
+	// MaxPixels caps decoded image size so a small file can't expand into
+	// gigabytes of pixels.
+	MaxPixels = 40_000_000
+	// MaxGIFFrames and MaxGIFPixels cap animations, whose frames are all
+	// decoded at once: every frame counts towards MaxGIFPixels, so a GIF
+	// can't get past MaxPixels by repeating a frame thousands of times.
+	MaxGIFFrames = 500
+	MaxGIFPixels = 100_000_000
+	// ThumbnailSize is the longest side of a thumbnail.
+	ThumbnailSize = 320
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooLarge        = errors.New("image dimensions too large")
+	ErrCorrupt         = errors.New("image could not be decoded")
+)
+
+// Image is an upload that's been checked, stripped of metadata and given a
+// thumbnail.
+type Image struct {
+	ContentType   string
+	Data          []byte
+	Width         int
+	Height        int
+	Thumbnail     []byte
+	ThumbnailType string
+}
+
+// DetectType identifies an image by its magic bytes, ignoring whatever the
+// client claimed the file was.
+func DetectType(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return TypeJPEG, nil
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return TypePNG, nil
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return TypeGIF, nil
+	}
+	return "", ErrUnsupportedType
+}
+
+// Process validates an upload, strips its metadata and renders a thumbnail.
+func Process(data []byte) (Image, error) {
+	contentType, err := DetectType(data)
+	if err != nil {
+		return Image{}, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrCorrupt
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return Image{}, ErrTooLarge
+	}
+	if contentType == TypeGIF {
+		if err := checkGIFFrames(data); err != nil {
+			return Image{}, err
+		}
+	}
+
+	var clean []byte
+	switch contentType {
+	case TypeJPEG:
+		clean, err = stripJPEG(data)
+	case TypePNG:
+		clean, err = stripPNG(data)
+	case TypeGIF:
+		clean, err = stripGIF(data)
+	}
+	if err != nil {
+		return Image{}, ErrCorrupt
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(clean))
+	if err != nil {
+		return Image{}, ErrCorrupt
+	}
+
+	thumb, thumbType, err := thumbnail(img, contentType)
+	if err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		ContentType:   contentType,
+		Data:          clean,
+		Width:         img.Bounds().Dx(),
+		Height:        img.Bounds().Dy(),
+		Thumbnail:     thumb,
+		ThumbnailType: thumbType,
+	}, nil
+}
+
+// stripJPEG drops the segments that carry metadata (EXIF and XMP in APP1,
+// IPTC in APP13, and comments) without re-encoding. Since that also drops
+// the EXIF orientation, rotated photos are turned upright and re-encoded
+// first.
+func stripJPEG(data []byte) ([]byte, error) {
+	if orientation := jpegOrientation(data); orientation > 1 {
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, orient(img, orientation), &jpeg.Options{Quality: 90}); err != nil {
+			return nil, err
+		}
+		// Go's encoder writes no metadata, so there's nothing left to strip.
+		return buf.Bytes(), nil
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return nil, ErrCorrupt
+		}
+		marker := data[i+1]
+		// Fill bytes before a marker.
+		if marker == 0xFF {
+			i++
+			continue
+		}
+		// Markers without a length.
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD8) {
+			out.Write(data[i : i+2])
+			i += 2
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, ErrCorrupt
+		}
+		if marker == 0xDA {
+			// Start of scan: the rest is image data through to EOI.
+			out.Write(data[i:])
+			return out.Bytes(), nil
+		}
+		if marker != 0xE1 && marker != 0xED && marker != 0xFE {
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return nil, ErrCorrupt
+}
+
+// jpegOrientation returns the EXIF orientation tag, or 0 if there isn't one.
+func jpegOrientation(data []byte) int {
+	i := 2
+	for i+4 <= len(data) && data[i] == 0xFF {
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) || marker == 0xDA {
+			return 0
+		}
+		seg := data[i+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return tiffOrientation(seg[6:])
+		}
+		i = end
+	}
+	return 0
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for n := 0; n < count; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+	return 0
+}
+
+// orient applies an EXIF orientation (2-8) so the image displays upright
+// without the tag.
+func orient(img image.Image, orientation int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if orientation >= 5 {
+		w, h = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = w-1-y, x
+			case 7:
+				dx, dy = w-1-y, h-1-x
+			case 8:
+				dx, dy = y, h-1-x
+			default:
+				dx, dy = x, y
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// stripPNG drops the ancillary chunks that hold metadata: EXIF, text and
+// timestamps. Everything else is copied unchanged.
+func stripPNG(data []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:8])
+	i := 8
+	for i+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[i : i+4]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, ErrCorrupt
+		}
+		switch string(data[i+4 : i+8]) {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out.Write(data[i:end])
+		}
+		if string(data[i+4:i+8]) == "IEND" {
+			return out.Bytes(), nil
+		}
+		i = end
+	}
+	return nil, ErrCorrupt
+}
+
+// checkGIFFrames walks the GIF's blocks without decoding any image data and
+// rejects it if the frames would add up to more than MaxGIFFrames or
+// MaxGIFPixels once decoded.
+func checkGIFFrames(data []byte) error {
+	// Header and logical screen descriptor.
+	if len(data) < 13 {
+		return ErrCorrupt
+	}
+	i := 13
+	if data[10]&0x80 != 0 {
+		i += 3 << (data[10]&0x07 + 1)
+	}
+
+	frames, pixels := 0, 0
+	for i < len(data) {
+		switch data[i] {
+		case 0x21:
+			// Extension: a label, then sub-blocks.
+			i += 2
+		case 0x2C:
+			if i+10 > len(data) {
+				return ErrCorrupt
+			}
+			w := int(binary.LittleEndian.Uint16(data[i+5 : i+7]))
+			h := int(binary.LittleEndian.Uint16(data[i+7 : i+9]))
+			frames++
+			pixels += w * h
+			if frames > MaxGIFFrames || pixels > MaxGIFPixels {
+				return ErrTooLarge
+			}
+			packed := data[i+9]
+			i += 10
+			if packed&0x80 != 0 {
+				i += 3 << (packed&0x07 + 1)
+			}
+			// LZW minimum code size, then the image data sub-blocks.
+			i++
+		case 0x3B:
+			return nil
+		default:
+			return ErrCorrupt
+		}
+
+		for {
+			if i >= len(data) {
+				return ErrCorrupt
+			}
+			n := int(data[i])
+			i += n + 1
+			if n == 0 {
+				break
+			}
+		}
+	}
+	return ErrCorrupt
+}
+
+// stripGIF re-encodes the GIF, which keeps every frame and the loop count
+// but drops comment and application extensions such as XMP.
+func stripGIF(data []byte) ([]byte, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// thumbnail scales img to fit within ThumbnailSize on its longest side.
+// Photos stay JPEGs; PNGs and GIFs become PNGs so transparency survives.
+func thumbnail(img image.Image, contentType string) ([]byte, string, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > ThumbnailSize || h > ThumbnailSize {
+		if w >= h {
+			w, h = ThumbnailSize, max(1, h*ThumbnailSize/w)
+		} else {
+			w, h = max(1, w*ThumbnailSize/h), ThumbnailSize
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if contentType == TypeJPEG {
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), TypeJPEG, nil
+	}
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), TypePNG, nil
+}
@@ -0,0 +1,245 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 100, 255})
+		}
+	}
+	return img
+}
+
+// exifSegment builds an APP1 segment holding a little-endian TIFF header
+// with just an orientation tag.
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xFF, 0xE1}
+	seg = binary.BigEndian.AppendUint16(seg, uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+func jpegWithSegments(t *testing.T, w, h int, segments ...[]byte) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(w, h), nil); err != nil {
+		t.Fatalf("Error encoding jpeg: %v", err)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	for _, seg := range segments {
+		out = append(out, seg...)
+	}
+	return append(out, data[2:]...)
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, typ...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(append([]byte(typ), data...)))
+}
+
+func TestDetectType(t *testing.T) {
+	cases := map[string][]byte{
+		TypeJPEG: {0xFF, 0xD8, 0xFF, 0xE0},
+		TypePNG:  []byte("\x89PNG\r\n\x1a\nrest"),
+		TypeGIF:  []byte("GIF89a..."),
+	}
+	for want, data := range cases {
+		got, err := DetectType(data)
+		if err != nil || got != want {
+			t.Fatalf("Expected %s but got %s (%v)", want, got, err)
+		}
+	}
+
+	for _, data := range [][]byte{[]byte("<svg></svg>"), []byte("RIFF\x00\x00\x00\x00WEBP"), {}} {
+		if _, err := DetectType(data); err != ErrUnsupportedType {
+			t.Fatalf("Expected %q to be unsupported but got: %v", data, err)
+		}
+	}
+}
+
+func TestProcessStripsJPEGMetadata(t *testing.T) {
+	comment := append([]byte{0xFF, 0xFE, 0x00, 0x0C}, "secret gps"...)
+	data := jpegWithSegments(t, 40, 20, exifSegment(1), comment)
+
+	img, err := Process(data)
+	if err != nil {
+		t.Fatalf("Error processing jpeg: %v", err)
+	}
+	if bytes.Contains(img.Data, []byte("Exif")) || bytes.Contains(img.Data, []byte("secret gps")) {
+		t.Fatalf("Expected metadata to be stripped")
+	}
+	if img.ContentType != TypeJPEG || img.Width != 40 || img.Height != 20 {
+		t.Fatalf("Unexpected result: %s %dx%d", img.ContentType, img.Width, img.Height)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(img.Data)); err != nil {
+		t.Fatalf("Expected stripped jpeg to decode: %v", err)
+	}
+}
+
+func TestProcessAppliesJPEGOrientation(t *testing.T) {
+	data := jpegWithSegments(t, 40, 20, exifSegment(6))
+
+	img, err := Process(data)
+	if err != nil {
+		t.Fatalf("Error processing jpeg: %v", err)
+	}
+	if img.Width != 20 || img.Height != 40 {
+		t.Fatalf("Expected a rotated 20x40 image but got %dx%d", img.Width, img.Height)
+	}
+	if bytes.Contains(img.Data, []byte("Exif")) {
+		t.Fatalf("Expected metadata to be stripped")
+	}
+}
+
+func TestProcessStripsPNGMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(10, 10)); err != nil {
+		t.Fatalf("Error encoding png: %v", err)
+	}
+	data := buf.Bytes()
+	iend := len(data) - 12
+	withText := append([]byte{}, data[:iend]...)
+	withText = append(withText, pngChunk("tEXt", []byte("Author\x00someone"))...)
+	withText = append(withText, data[iend:]...)
+
+	img, err := Process(withText)
+	if err != nil {
+		t.Fatalf("Error processing png: %v", err)
+	}
+	if bytes.Contains(img.Data, []byte("tEXt")) {
+		t.Fatalf("Expected text chunk to be stripped")
+	}
+	if !bytes.Equal(img.Data, data) {
+		t.Fatalf("Expected the rest of the png to be unchanged")
+	}
+}
+
+func TestProcessKeepsGIFFrames(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			image.NewPaletted(image.Rect(0, 0, 8, 8), palette),
+			image.NewPaletted(image.Rect(0, 0, 8, 8), palette),
+		},
+		Delay: []int{10, 10},
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("Error encoding gif: %v", err)
+	}
+
+	img, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Error processing gif: %v", err)
+	}
+	out, err := gif.DecodeAll(bytes.NewReader(img.Data))
+	if err != nil || len(out.Image) != 2 {
+		t.Fatalf("Expected both frames to survive: %v", err)
+	}
+	if img.ThumbnailType != TypePNG {
+		t.Fatalf("Expected a png thumbnail but got %s", img.ThumbnailType)
+	}
+}
+
+func TestProcessThumbnailFitsBox(t *testing.T) {
+	img, err := Process(jpegWithSegments(t, 800, 400))
+	if err != nil {
+		t.Fatalf("Error processing jpeg: %v", err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(img.Thumbnail))
+	if err != nil {
+		t.Fatalf("Error decoding thumbnail: %v", err)
+	}
+	if cfg.Width != ThumbnailSize || cfg.Height != ThumbnailSize/2 {
+		t.Fatalf("Expected a %dx%d thumbnail but got %dx%d", ThumbnailSize, ThumbnailSize/2, cfg.Width, cfg.Height)
+	}
+}
+
+func TestProcessRejectsHugeDimensions(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(1, 1)); err != nil {
+		t.Fatalf("Error encoding png: %v", err)
+	}
+	data := buf.Bytes()
+	// Rewrite IHDR to claim 100000x100000 pixels.
+	ihdr := append([]byte{}, data[16:29]...)
+	binary.BigEndian.PutUint32(ihdr[0:4], 100000)
+	binary.BigEndian.PutUint32(ihdr[4:8], 100000)
+	forged := append([]byte{}, data[:8]...)
+	forged = append(forged, pngChunk("IHDR", ihdr)...)
+	forged = append(forged, data[33:]...)
+
+	if _, err := Process(forged); err != ErrTooLarge {
+		t.Fatalf("Expected ErrTooLarge but got: %v", err)
+	}
+}
+
+func TestProcessRejectsTooManyGIFFrames(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{}
+	for range MaxGIFFrames + 1 {
+		g.Image = append(g.Image, image.NewPaletted(image.Rect(0, 0, 1, 1), palette))
+		g.Delay = append(g.Delay, 0)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("Error encoding gif: %v", err)
+	}
+
+	if _, err := Process(buf.Bytes()); err != ErrTooLarge {
+		t.Fatalf("Expected ErrTooLarge but got: %v", err)
+	}
+}
+
+func TestProcessRejectsHugeGIFAnimation(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{
+		Image: []*image.Paletted{image.NewPaletted(image.Rect(0, 0, 1, 1), palette)},
+		Delay: []int{0},
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("Error encoding gif: %v", err)
+	}
+	data := buf.Bytes()
+
+	// Claim a 6000x6000 screen, under MaxPixels, and repeat a frame that
+	// claims to fill it until the frames together go over MaxGIFPixels.
+	frameAt := bytes.IndexByte(data[13:], 0x2C) + 13
+	frame := append([]byte{}, data[frameAt:len(data)-1]...)
+	binary.LittleEndian.PutUint16(frame[5:7], 6000)
+	binary.LittleEndian.PutUint16(frame[7:9], 6000)
+	forged := append([]byte{}, data[:frameAt]...)
+	binary.LittleEndian.PutUint16(forged[6:8], 6000)
+	binary.LittleEndian.PutUint16(forged[8:10], 6000)
+	for range MaxGIFPixels/(6000*6000) + 1 {
+		forged = append(forged, frame...)
+	}
+	forged = append(forged, 0x3B)
+
+	if _, err := Process(forged); err != ErrTooLarge {
+		t.Fatalf("Expected ErrTooLarge but got: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package media
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error creating store: %v", err)
+	}
+	ctx := context.Background()
+
+	key, err := s.Put(ctx, []byte("hello"))
+	if err != nil {
+		t.Fatalf("Error storing blob: %v", err)
+	}
+	if key != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Fatalf("Expected the SHA-256 of the content but got %s", key)
+	}
+
+	again, err := s.Put(ctx, []byte("hello"))
+	if err != nil || again != key {
+		t.Fatalf("Expected storing the same content to return the same key")
+	}
+
+	r, err := s.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Error opening blob: %v", err)
+	}
+	defer r.Close()
+	data, _ := io.ReadAll(r)
+	if string(data) != "hello" {
+		t.Fatalf("Expected hello but got %q", data)
+	}
+}
+
+func TestLocalStoreRejectsBadKeys(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error creating store: %v", err)
+	}
+
+	for _, key := range []string{"../../etc/passwd", "", "2cf24dba"} {
+		if _, err := s.Open(context.Background(), key); err != ErrNotFound {
+			t.Fatalf("Expected ErrNotFound for %q but got: %v", key, err)
+		}
+	}
+	missing := "0000000000000000000000000000000000000000000000000000000000000000"
+	if _, err := s.Open(context.Background(), missing); err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound for a missing blob but got: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package mention
+
+import (
+	"strings"
+	"unicode"
+)
+
+const maxHandleLength = 15
+
+// Mention is an @handle found in a chirp body. Start and End are offsets in
+// Unicode code points (not bytes), End exclusive, and cover the '@' as well
+// as the handle.
+type Mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// ValidHandle reports whether h can be used as a handle: 1-15 ASCII letters,
+// digits or underscores.
+func ValidHandle(h string) bool {
+	if h == "" || len(h) > maxHandleLength {
+		return false
+	}
+	for _, r := range h {
+		if !isHandleRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Extract returns every @handle in body. An '@' straight after a letter or
+// digit is skipped so email addresses aren't picked up, as is any run that is
+// too long to be a handle.
+func Extract(body string) []Mention {
+	var mentions []Mention
+	runes := []rune(body)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' {
+			continue
+		}
+		if i > 0 && (unicode.IsLetter(runes[i-1]) || unicode.IsDigit(runes[i-1]) || runes[i-1] == '_' || runes[i-1] == '@') {
+			continue
+		}
+
+		j := i + 1
+		for j < len(runes) && isHandleRune(runes[j]) {
+			j++
+		}
+		handle := string(runes[i+1 : j])
+		if ValidHandle(handle) && (j == len(runes) || runes[j] != '@') {
+			mentions = append(mentions, Mention{Handle: handle, Start: i, End: j})
+		}
+		i = j - 1
+	}
+
+	return mentions
+}
+
+// Normalize is the form handles are compared in.
+func Normalize(h string) string {
+	return strings.ToLower(strings.TrimPrefix(h, "@"))
+}
+
+func isHandleRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
@@ -0,0 +1,40 @@
+package mention
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		body string
+		want []Mention
+	}{
+		{"nobody here", nil},
+		{"@alice hi", []Mention{{Handle: "alice", Start: 0, End: 6}}},
+		{"héllo @Bob_1, @carol!", []Mention{{Handle: "Bob_1", Start: 6, End: 12}, {Handle: "carol", Start: 14, End: 20}}},
+		{"mail me@example.com", nil},
+		{"@waytoolonghandle123 @ok", []Mention{{Handle: "ok", Start: 21, End: 24}}},
+		{"@@double @a@b", nil},
+	}
+
+	for _, tc := range tests {
+		got := Extract(tc.body)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("Extract(%q): expected %v but got %v", tc.body, tc.want, got)
+		}
+	}
+}
+
+func TestValidHandle(t *testing.T) {
+	for _, h := range []string{"a", "Chirpy_Fan_2024", "___"} {
+		if !ValidHandle(h) {
+			t.Fatalf("Expected %q to be a valid handle", h)
+		}
+	}
+	for _, h := range []string{"", "with space", "émile", "sixteen_chars_xx", "dash-ed"} {
+		if ValidHandle(h) {
+			t.Fatalf("Expected %q to be an invalid handle", h)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type Kind string
+
+const (
+	KindReply      Kind = "reply"
+	KindMention    Kind = "mention"
+	KindLike       Kind = "like"
+	KindFollow     Kind = "follow"
+	KindRedUpgrade Kind = "chirpy_red"
+	KindPollClosed Kind = "poll_closed"
+)
+
+// Event is something that happened to UserID. ActorID is who did it, if
+// anyone, and ChirpID the chirp it happened to, if any.
+type Event struct {
+	Kind    Kind
+	UserID  uuid.UUID
+	ActorID uuid.NullUUID
+	ChirpID uuid.NullUUID
+}
+
+// GroupKey decides which events are coalesced into a single notification:
+// every like on a chirp shares one, as does every new follower, while each
+// mention stays separate.
+func GroupKey(e Event) string {
+	switch e.Kind {
+	case KindFollow, KindRedUpgrade:
+		return string(e.Kind)
+	default:
+		return string(e.Kind) + ":" + e.ChirpID.UUID.String()
+	}
+}
+
+// Message renders a notification for display, e.g. "5 people liked your
+// chirp".
+func Message(kind string, actorCount int32) string {
+	who := "Someone"
+	if actorCount > 1 {
+		who = fmt.Sprintf("%d people", actorCount)
+	}
+
+	switch Kind(kind) {
+	case KindReply:
+		return who + " replied to your chirp"
+	case KindMention:
+		return who + " mentioned you in a chirp"
+	case KindLike:
+		return who + " liked your chirp"
+	case KindFollow:
+		return who + " followed you"
+	case KindRedUpgrade:
+		return "You're now a Chirpy Red member"
+	case KindPollClosed:
+		return "Your poll has closed"
+	default:
+		return who + " interacted with you"
+	}
+}
+
+// Notifier writes notifications in the background so handlers never wait on
+// them. Events are queued on a bounded channel; if it fills up, new events
+// are dropped and logged rather than blocking the request.
+type Notifier struct {
+	db      *sql.DB
+	queries *database.Queries
+	events  chan Event
+	wg      sync.WaitGroup
+
+	// onStored is called after each notification is written, e.g. to push
+	// it to connected clients.
+	onStored func(e Event, notificationID uuid.UUID)
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewNotifier(db *sql.DB, queries *database.Queries, buffer int) *Notifier {
+	return &Notifier{
+		db:      db,
+		queries: queries,
+		events:  make(chan Event, buffer),
+	}
+}
+
+// OnStored registers fn to be called from the worker after each event is
+// written. It must be set before Start.
+func (n *Notifier) OnStored(fn func(e Event, notificationID uuid.UUID)) {
+	n.onStored = fn
+}
+
+// Start launches the given number of workers draining the queue.
+func (n *Notifier) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			for e := range n.events {
+				id, err := n.store(e)
+				if err != nil {
+					log.Printf("Error storing %s notification for %s: %v", e.Kind, e.UserID, err)
+					continue
+				}
+				if n.onStored != nil {
+					n.onStored(e, id)
+				}
+			}
+		}()
+	}
+}
+
+// Notify queues e. Users are never notified about their own actions.
+func (n *Notifier) Notify(e Event) {
+	if e.ActorID.Valid && e.ActorID.UUID == e.UserID {
+		return
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.closed {
+		return
+	}
+
+	select {
+	case n.events <- e:
+	default:
+		log.Printf("Notification queue full, dropping %s notification for %s", e.Kind, e.UserID)
+	}
+}
+
+// Close stops accepting events and waits for the queued ones to be written.
+func (n *Notifier) Close() {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.events)
+	}
+	n.mu.Unlock()
+	n.wg.Wait()
+}
+
+// store merges e into the recipient's unread notification with the same
+// group key, or starts a new one. Actors are recorded per notification so the
+// same person liking, unliking and liking again is only counted once.
+func (n *Notifier) store(e Event) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := n.db.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+	qtx := n.queries.WithTx(tx)
+
+	id, err := qtx.UpsertNotification(ctx, database.UpsertNotificationParams{
+		UserID:        e.UserID,
+		Kind:          string(e.Kind),
+		ChirpID:       e.ChirpID,
+		GroupKey:      GroupKey(e),
+		LatestActorID: e.ActorID,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if e.ActorID.Valid {
+		err = qtx.AddNotificationActor(ctx, database.AddNotificationActorParams{
+			NotificationID: id,
+			ActorID:        e.ActorID.UUID,
+		})
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if err := qtx.RefreshNotificationActorCount(ctx, id); err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	return id, tx.Commit()
+}
@@ -0,0 +1,53 @@
+package notifications
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGroupKey(t *testing.T) {
+	chirpID := uuid.NullUUID{UUID: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"), Valid: true}
+	otherChirpID := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+
+	likeA := GroupKey(Event{Kind: KindLike, ActorID: uuid.NullUUID{UUID: uuid.New(), Valid: true}, ChirpID: chirpID})
+	likeB := GroupKey(Event{Kind: KindLike, ActorID: uuid.NullUUID{UUID: uuid.New(), Valid: true}, ChirpID: chirpID})
+	if likeA != likeB {
+		t.Fatalf("Expected likes on the same chirp to share a group key, got %s and %s", likeA, likeB)
+	}
+
+	if GroupKey(Event{Kind: KindLike, ChirpID: otherChirpID}) == likeA {
+		t.Fatalf("Expected likes on different chirps to have different group keys")
+	}
+	if GroupKey(Event{Kind: KindReply, ChirpID: chirpID}) == likeA {
+		t.Fatalf("Expected replies and likes to have different group keys")
+	}
+	if GroupKey(Event{Kind: KindFollow}) != "follow" {
+		t.Fatalf("Expected all follows to share a group key")
+	}
+}
+
+func TestMessage(t *testing.T) {
+	if m := Message(string(KindLike), 1); m != "Someone liked your chirp" {
+		t.Fatalf("Unexpected message: %s", m)
+	}
+	if m := Message(string(KindLike), 5); m != "5 people liked your chirp" {
+		t.Fatalf("Unexpected message: %s", m)
+	}
+	if m := Message(string(KindPollClosed), 0); m != "Your poll has closed" {
+		t.Fatalf("Unexpected message: %s", m)
+	}
+}
+
+func TestNotifySkipsSelfAndClosed(t *testing.T) {
+	n := NewNotifier(nil, nil, 1)
+	userID := uuid.New()
+
+	n.Notify(Event{Kind: KindLike, UserID: userID, ActorID: uuid.NullUUID{UUID: userID, Valid: true}})
+	if len(n.events) != 0 {
+		t.Fatalf("Expected self notification to be skipped")
+	}
+
+	n.Close()
+	n.Notify(Event{Kind: KindFollow, UserID: userID, ActorID: uuid.NullUUID{UUID: uuid.New(), Valid: true}})
+}
@@ -0,0 +1,82 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor points at the last row of a page. Rows are ordered by
+// (created_at, id) so the cursor stays stable when several chirps share a
+// timestamp.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func EncodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+
+	return Cursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// RankCursor points at the last row of a page of search results ordered by
+// relevance, with the ID breaking ties between equal ranks.
+type RankCursor struct {
+	Rank float32
+	ID   uuid.UUID
+}
+
+func EncodeRankCursor(rank float32, id uuid.UUID) string {
+	raw := strconv.FormatFloat(float64(rank), 'g', -1, 32) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeRankCursor(s string) (RankCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return RankCursor{}, errors.New("malformed cursor")
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return RankCursor{}, errors.New("malformed cursor")
+	}
+
+	rank, err := strconv.ParseFloat(parts[0], 32)
+	if err != nil {
+		return RankCursor{}, errors.New("malformed cursor")
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return RankCursor{}, errors.New("malformed cursor")
+	}
+
+	return RankCursor{Rank: float32(rank), ID: id}, nil
+}
@@ -0,0 +1,56 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id, err := uuid.Parse("123e4567-e89b-12d3-a456-426614174000")
+	if err != nil {
+		t.Fatalf("Some error happened: %v", err)
+	}
+	createdAt := time.Date(2024, 11, 20, 13, 37, 0, 123456000, time.UTC)
+
+	c, err := DecodeCursor(EncodeCursor(createdAt, id))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !c.CreatedAt.Equal(createdAt) {
+		t.Fatalf("Expected: %v but got: %v", createdAt, c.CreatedAt)
+	}
+	if c.ID != id {
+		t.Fatalf("Expected: %s but got: %s", id.String(), c.ID.String())
+	}
+}
+
+func TestDecodeCursorMalformed(t *testing.T) {
+	for _, s := range []string{"", "not-base64!", "bm8tc2VwYXJhdG9y", "YmFkfDEyMw"} {
+		if _, err := DecodeCursor(s); err == nil {
+			t.Fatalf("Expected an error decoding %q", s)
+		}
+	}
+}
+
+func TestRankCursorRoundTrip(t *testing.T) {
+	id, err := uuid.Parse("123e4567-e89b-12d3-a456-426614174000")
+	if err != nil {
+		t.Fatalf("Some error happened: %v", err)
+	}
+	var rank float32 = 0.0607927
+
+	c, err := DecodeRankCursor(EncodeRankCursor(rank, id))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if c.Rank != rank {
+		t.Fatalf("Expected: %v but got: %v", rank, c.Rank)
+	}
+	if c.ID != id {
+		t.Fatalf("Expected: %s but got: %s", id.String(), c.ID.String())
+	}
+}
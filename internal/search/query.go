@@ -0,0 +1,91 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const dateLayout = "2006-01-02"
+
+// Query is a parsed search string. The from:, since: and until: operators are
+// pulled out and everything else is left in Text for websearch_to_tsquery,
+// which already handles "quoted phrases", -excluded terms and OR.
+type Query struct {
+	Text  string
+	From  string
+	Since time.Time
+	Until time.Time
+}
+
+// ParseQuery splits q into its full-text part and its filters. since: and
+// until: take YYYY-MM-DD dates and are both inclusive, so Until is set to the
+// start of the following day.
+func ParseQuery(q string) (Query, error) {
+	query := Query{}
+	var terms []string
+
+	for _, token := range tokenize(q) {
+		key, value, ok := strings.Cut(token, ":")
+		if !ok || value == "" {
+			terms = append(terms, token)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "from":
+			query.From = value
+		case "since":
+			t, err := time.Parse(dateLayout, value)
+			if err != nil {
+				return Query{}, errors.New("since: must be a YYYY-MM-DD date")
+			}
+			query.Since = t
+		case "until":
+			t, err := time.Parse(dateLayout, value)
+			if err != nil {
+				return Query{}, errors.New("until: must be a YYYY-MM-DD date")
+			}
+			query.Until = t.AddDate(0, 0, 1)
+		default:
+			terms = append(terms, token)
+		}
+	}
+
+	query.Text = strings.Join(terms, " ")
+	if strings.TrimSpace(strings.Trim(query.Text, `"-`)) == "" {
+		return Query{}, errors.New("no search terms")
+	}
+
+	return query, nil
+}
+
+// tokenize splits on whitespace, keeping quoted phrases together so that
+// something like "from:me" inside quotes is searched for rather than treated
+// as a filter.
+func tokenize(q string) []string {
+	var tokens []string
+	var current strings.Builder
+	inQuote := false
+
+	for _, r := range q {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuote:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens
+}
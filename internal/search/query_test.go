@@ -0,0 +1,48 @@
+package search
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQueryOperators(t *testing.T) {
+	q, err := ParseQuery(`"big launch" -boring from:123e4567-e89b-12d3-a456-426614174000 since:2024-01-01 until:2024-01-31 rockets`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if q.Text != `"big launch" -boring rockets` {
+		t.Fatalf("Expected text without operators but got: %s", q.Text)
+	}
+	if q.From != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Fatalf("Expected from filter but got: %s", q.From)
+	}
+	if !q.Since.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected since: %v", q.Since)
+	}
+	if !q.Until.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected until to be the start of the next day but got: %v", q.Until)
+	}
+}
+
+func TestParseQueryQuotedOperator(t *testing.T) {
+	q, err := ParseQuery(`"from:space with love"`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if q.From != "" {
+		t.Fatalf("Expected quoted operator to be searched for, got from: %s", q.From)
+	}
+	if q.Text != `"from:space with love"` {
+		t.Fatalf("Unexpected text: %s", q.Text)
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, s := range []string{"", "   ", "from:someone", "since:yesterday rockets", `"" -`} {
+		if _, err := ParseQuery(s); err == nil {
+			t.Fatalf("Expected an error parsing %q", s)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventChirpCreated  = "chirp.created"
+	EventChirpUpdated  = "chirp.updated"
+	EventChirpDeleted  = "chirp.deleted"
+	EventChirpRestored = "chirp.restored"
+)
+
+// Event is a single change pushed to subscribers. IDs increase by one per
+// event and are only meaningful within this process. A nil Audience means
+// anyone may see the event; otherwise only the users in it can.
+type Event struct {
+	ID       uint64
+	Type     string
+	ChirpID  uuid.UUID
+	AuthorID uuid.UUID
+	Audience map[uuid.UUID]bool
+	Data     []byte
+}
+
+// VisibleTo reports whether viewer may receive e. uuid.Nil is an anonymous
+// subscriber.
+func (e Event) VisibleTo(viewer uuid.UUID) bool {
+	return e.Audience == nil || (viewer != uuid.Nil && e.Audience[viewer])
+}
+
+// Subscriber receives events on Events. If it falls so far behind that its
+// buffer fills up it is evicted and Events is closed; the client is expected
+// to reconnect and resume from the last ID it saw.
+type Subscriber struct {
+	Events chan Event
+	viewer uuid.UUID
+	filter func(Event) bool
+}
+
+// Broadcaster fans events out to subscribers and keeps the most recent ones
+// around so reconnecting clients can catch up.
+type Broadcaster struct {
+	mu         sync.Mutex
+	nextID     uint64
+	history    []Event
+	historyLen int
+	bufferSize int
+	subs       map[*Subscriber]struct{}
+	closed     bool
+}
+
+func NewBroadcaster(historyLen, bufferSize int) *Broadcaster {
+	return &Broadcaster{
+		nextID:     1,
+		historyLen: historyLen,
+		bufferSize: bufferSize,
+		subs:       map[*Subscriber]struct{}{},
+	}
+}
+
+// Publish assigns the event its ID and delivers it to every subscriber whose
+// filter accepts it, without ever blocking on a slow one.
+func (b *Broadcaster) Publish(typ string, chirpID, authorID uuid.UUID, data []byte) Event {
+	return b.PublishTo(typ, chirpID, authorID, nil, data)
+}
+
+// PublishTo is Publish for an event only the users in audience may see.
+func (b *Broadcaster) PublishTo(typ string, chirpID, authorID uuid.UUID, audience map[uuid.UUID]bool, data []byte) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e := Event{
+		ID:       b.nextID,
+		Type:     typ,
+		ChirpID:  chirpID,
+		AuthorID: authorID,
+		Audience: audience,
+		Data:     data,
+	}
+	b.nextID++
+
+	b.history = append(b.history, e)
+	if len(b.history) > b.historyLen {
+		b.history = b.history[len(b.history)-b.historyLen:]
+	}
+
+	for s := range b.subs {
+		if !s.accepts(e) {
+			continue
+		}
+		select {
+		case s.Events <- e:
+		default:
+			b.evict(s)
+		}
+	}
+
+	return e
+}
+
+// Subscribe registers a subscriber and returns, along with it, the retained
+// events after lastEventID that it would have received. Both happen under
+// the same lock so nothing falls between the backlog and the live stream.
+// viewer is who the subscriber is, or uuid.Nil if they're anonymous; events
+// they aren't in the audience for are never delivered, whatever filter says.
+func (b *Broadcaster) Subscribe(lastEventID uint64, viewer uuid.UUID, filter func(Event) bool) (*Subscriber, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := &Subscriber{
+		viewer: viewer,
+		filter: filter,
+	}
+	if b.closed {
+		s.Events = make(chan Event)
+		close(s.Events)
+		return s, nil
+	}
+
+	var backlog []Event
+	if lastEventID > 0 {
+		for _, e := range b.history {
+			if e.ID > lastEventID && s.accepts(e) {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	// Live events queue up while the caller is still sending the backlog, so
+	// the buffer gets room for as many again on top of the usual size.
+	s.Events = make(chan Event, b.bufferSize+len(backlog))
+	b.subs[s] = struct{}{}
+
+	return s, backlog
+}
+
+func (s *Subscriber) accepts(e Event) bool {
+	return e.VisibleTo(s.viewer) && (s.filter == nil || s.filter(e))
+}
+
+func (b *Broadcaster) Unsubscribe(s *Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.evict(s)
+}
+
+// Close disconnects every subscriber and turns away new ones, so long-lived
+// stream handlers return when the server shuts down.
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		b.evict(s)
+	}
+}
+
+func (b *Broadcaster) evict(s *Subscriber) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	close(s.Events)
+}
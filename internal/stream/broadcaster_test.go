@@ -0,0 +1,124 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPublishFiltersByAuthor(t *testing.T) {
+	b := NewBroadcaster(10, 10)
+	alice, bob := uuid.New(), uuid.New()
+
+	s, _ := b.Subscribe(0, uuid.Nil, func(e Event) bool { return e.AuthorID == alice })
+	b.Publish(EventChirpCreated, uuid.New(), bob, nil)
+	want := b.Publish(EventChirpCreated, uuid.New(), alice, nil)
+
+	got := <-s.Events
+	if got.ID != want.ID {
+		t.Fatalf("Expected event %d but got %d", want.ID, got.ID)
+	}
+	if len(s.Events) != 0 {
+		t.Fatalf("Expected filtered events to be skipped")
+	}
+}
+
+func TestSubscribeResumesFromLastEventID(t *testing.T) {
+	b := NewBroadcaster(3, 10)
+	author := uuid.New()
+	for i := 0; i < 5; i++ {
+		b.Publish(EventChirpCreated, uuid.New(), author, nil)
+	}
+
+	_, backlog := b.Subscribe(3, uuid.Nil, nil)
+	if len(backlog) != 2 || backlog[0].ID != 4 || backlog[1].ID != 5 {
+		t.Fatalf("Expected events 4 and 5 in the backlog but got %v", backlog)
+	}
+
+	_, backlog = b.Subscribe(0, uuid.Nil, nil)
+	if len(backlog) != 0 {
+		t.Fatalf("Expected no backlog without a Last-Event-ID but got %d events", len(backlog))
+	}
+}
+
+func TestBacklogWidensBuffer(t *testing.T) {
+	b := NewBroadcaster(10, 1)
+	for i := 0; i < 5; i++ {
+		b.Publish(EventChirpCreated, uuid.New(), uuid.New(), nil)
+	}
+
+	s, backlog := b.Subscribe(1, uuid.Nil, nil)
+	if len(backlog) != 4 {
+		t.Fatalf("Expected 4 events in the backlog but got %d", len(backlog))
+	}
+	for i := 0; i < 5; i++ {
+		b.Publish(EventChirpCreated, uuid.New(), uuid.New(), nil)
+	}
+	if len(s.Events) != 5 {
+		t.Fatalf("Expected all 5 live events to be buffered but got %d", len(s.Events))
+	}
+
+	b.Unsubscribe(s)
+}
+
+func TestSlowSubscriberIsEvicted(t *testing.T) {
+	b := NewBroadcaster(10, 1)
+	slow, _ := b.Subscribe(0, uuid.Nil, nil)
+	fast, _ := b.Subscribe(0, uuid.Nil, nil)
+
+	b.Publish(EventChirpCreated, uuid.New(), uuid.New(), nil)
+	<-fast.Events
+	b.Publish(EventChirpCreated, uuid.New(), uuid.New(), nil)
+
+	<-slow.Events
+	if _, ok := <-slow.Events; ok {
+		t.Fatalf("Expected slow subscriber to be evicted")
+	}
+	if _, ok := <-fast.Events; !ok {
+		t.Fatalf("Expected fast subscriber to keep receiving events")
+	}
+
+	b.Unsubscribe(slow)
+	b.Unsubscribe(fast)
+	if _, ok := <-fast.Events; ok {
+		t.Fatalf("Expected Events to be closed after unsubscribing")
+	}
+}
+
+func TestCloseDisconnectsSubscribers(t *testing.T) {
+	b := NewBroadcaster(10, 10)
+	s, _ := b.Subscribe(0, uuid.Nil, nil)
+	b.Close()
+
+	if _, ok := <-s.Events; ok {
+		t.Fatalf("Expected Events to be closed")
+	}
+
+	late, _ := b.Subscribe(0, uuid.Nil, nil)
+	if _, ok := <-late.Events; ok {
+		t.Fatalf("Expected subscribers after Close to be turned away")
+	}
+	b.Unsubscribe(late)
+}
+
+func TestAudienceLimitsDelivery(t *testing.T) {
+	b := NewBroadcaster(10, 10)
+	author := uuid.New()
+	follower := uuid.New()
+	anon, _ := b.Subscribe(0, uuid.Nil, nil)
+	stranger, _ := b.Subscribe(0, uuid.New(), nil)
+	allowed, _ := b.Subscribe(0, follower, nil)
+
+	want := b.PublishTo(EventChirpCreated, uuid.New(), author, map[uuid.UUID]bool{author: true, follower: true}, nil)
+	if got := <-allowed.Events; got.ID != want.ID {
+		t.Fatalf("Expected event %d, got %d", want.ID, got.ID)
+	}
+	if len(anon.Events) != 0 || len(stranger.Events) != 0 {
+		t.Fatalf("Expected subscribers outside the audience to get nothing")
+	}
+
+	_, backlog := b.Subscribe(want.ID-1, uuid.New(), nil)
+	if len(backlog) != 0 {
+		t.Fatalf("Expected backlog to respect the audience, got %d events", len(backlog))
+	}
+}
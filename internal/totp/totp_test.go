@@ -0,0 +1,82 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key from the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeMatchesRFCVectors(t *testing.T) {
+	// The RFC lists eight digit codes; six digit ones are their last six.
+	vectors := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, v := range vectors {
+		got, err := Code(rfcSecret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("Error computing code at %d: %v", v.unix, err)
+		}
+		if got != v.want {
+			t.Fatalf("At %d expected %s but got %s", v.unix, v.want, got)
+		}
+	}
+}
+
+func TestValidateAllowsOneStepOfDrift(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, err := Code(rfcSecret, Step(now)-1)
+	if err != nil {
+		t.Fatalf("Error computing code: %v", err)
+	}
+
+	step, ok := Validate(rfcSecret, code, now)
+	if !ok {
+		t.Fatalf("Expected the previous step's code to validate")
+	}
+	if step != Step(now)-1 {
+		t.Fatalf("Expected step %d but got %d", Step(now)-1, step)
+	}
+
+	if _, ok := Validate(rfcSecret, code, now.Add(2*Period)); ok {
+		t.Fatalf("Expected a code three steps old to be rejected")
+	}
+	if _, ok := Validate(rfcSecret, "12345", now); ok {
+		t.Fatalf("Expected a short code to be rejected")
+	}
+}
+
+func TestGenerateSecretRoundTrips(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("Error generating secret: %v", err)
+	}
+	now := time.Now()
+	code, err := Code(secret, Step(now))
+	if err != nil {
+		t.Fatalf("Error computing code: %v", err)
+	}
+	if _, ok := Validate(secret, code, now); !ok {
+		t.Fatalf("Expected a fresh code to validate")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("ABC", "Chirpy", "walt@example.com")
+	if !strings.HasPrefix(uri, "otpauth://totp/Chirpy:walt@example.com?") {
+		t.Fatalf("Unexpected URI: %s", uri)
+	}
+	if !strings.Contains(uri, "secret=ABC") || !strings.Contains(uri, "issuer=Chirpy") {
+		t.Fatalf("Expected secret and issuer in URI: %s", uri)
+	}
+}
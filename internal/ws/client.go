@@ -0,0 +1,204 @@
+package ws
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/hashtag"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// client is one websocket connection. The read pump handles incoming
+// messages and the write pump is the only goroutine that writes data frames.
+type client struct {
+	hub  *Hub
+	conn *websocket.Conn
+	send chan []byte
+
+	// closed and subs are guarded by hub.mu.
+	closed bool
+	subs   map[string]struct{}
+
+	mu          sync.Mutex
+	userID      uuid.UUID
+	expiresAt   time.Time
+	warnTimer   *time.Timer
+	expireTimer *time.Timer
+}
+
+func (c *client) readPump() {
+	defer func() {
+		c.hub.remove(c)
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(c.hub.PongWait))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(c.hub.PongWait))
+		return nil
+	})
+
+	for {
+		var m Message
+		if err := c.conn.ReadJSON(&m); err != nil {
+			return
+		}
+		c.handle(m)
+	}
+}
+
+func (c *client) writePump() {
+	ticker := time.NewTicker(c.hub.PingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(c.hub.WriteWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(c.hub.WriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *client) handle(m Message) {
+	if m.Type == TypeAuth {
+		c.reauthenticate(m.Token)
+		return
+	}
+
+	userID := c.currentUser()
+	if userID == uuid.Nil {
+		c.hub.send(c, Message{Type: TypeError, Error: "Authenticate first"})
+		return
+	}
+
+	switch m.Type {
+	case TypeSubscribe:
+		name, key, ok := resolveChannel(m.Channel, userID)
+		if !ok {
+			c.hub.send(c, Message{Type: TypeError, Channel: m.Channel, Error: "Unknown channel"})
+			return
+		}
+		c.hub.subscribe(c, key)
+		c.hub.send(c, Message{Type: TypeSubscribed, Channel: name})
+	case TypeUnsubscribe:
+		name, key, ok := resolveChannel(m.Channel, userID)
+		if !ok {
+			c.hub.send(c, Message{Type: TypeError, Channel: m.Channel, Error: "Unknown channel"})
+			return
+		}
+		c.hub.unsubscribe(c, key)
+		c.hub.send(c, Message{Type: TypeUnsubscribed, Channel: name})
+	default:
+		c.hub.send(c, Message{Type: TypeError, Error: "Unknown message type"})
+	}
+}
+
+// reauthenticate swaps in a fresh token. It has to belong to the same user;
+// switching accounts means opening a new connection.
+func (c *client) reauthenticate(token string) {
+	userID, expiresAt, err := c.hub.authenticate(token)
+	if err != nil {
+		c.hub.send(c, Message{Type: TypeError, Error: "Something went wrong with validating the jwt"})
+		return
+	}
+	if current := c.currentUser(); current != uuid.Nil && current != userID {
+		c.hub.send(c, Message{Type: TypeError, Error: "Token belongs to a different user"})
+		return
+	}
+
+	c.setAuth(userID, expiresAt)
+	c.hub.send(c, Message{Type: TypeAuthenticated, ExpiresAt: &expiresAt})
+}
+
+func (c *client) currentUser() uuid.UUID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.userID
+}
+
+// setAuth records the token's owner and expiry, asks for a new token
+// ReauthGrace before it runs out and closes the connection once it has.
+func (c *client) setAuth(userID uuid.UUID, expiresAt time.Time) {
+	c.mu.Lock()
+	c.userID = userID
+	c.expiresAt = expiresAt
+	if c.warnTimer != nil {
+		c.warnTimer.Stop()
+	}
+	c.warnTimer = time.AfterFunc(time.Until(expiresAt.Add(-c.hub.ReauthGrace)), func() {
+		c.hub.send(c, Message{Type: TypeReauthRequired, ExpiresAt: &expiresAt})
+	})
+	c.mu.Unlock()
+
+	c.armDeadline(expiresAt, "token expired")
+}
+
+func (c *client) armDeadline(at time.Time, reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.expireTimer != nil {
+		c.expireTimer.Stop()
+	}
+	c.expireTimer = time.AfterFunc(time.Until(at), func() {
+		msg := websocket.FormatCloseMessage(CloseAuthExpired, reason)
+		c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(c.hub.WriteWait))
+		c.conn.Close()
+	})
+}
+
+func (c *client) stopTimers() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.warnTimer != nil {
+		c.warnTimer.Stop()
+	}
+	if c.expireTimer != nil {
+		c.expireTimer.Stop()
+	}
+}
+
+// resolveChannel turns the channel a client asked for into its canonical
+// name, which is what events are tagged with, and the key it's stored under
+// in the hub.
+func resolveChannel(channel string, userID uuid.UUID) (string, string, bool) {
+	if channel == NotificationsChannel {
+		return channel, notificationsKey(userID), true
+	}
+	kind, rest, ok := strings.Cut(channel, ":")
+	if !ok {
+		return "", "", false
+	}
+	switch kind {
+	case "user":
+		id, err := uuid.Parse(rest)
+		if err != nil {
+			return "", "", false
+		}
+		return UserChannel(id), UserChannel(id), true
+	case "hashtag":
+		tag := hashtag.Normalize(rest)
+		if tag == "" {
+			return "", "", false
+		}
+		return HashtagChannel(tag), HashtagChannel(tag), true
+	}
+	return "", "", false
+}
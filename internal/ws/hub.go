@@ -0,0 +1,272 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// Message types. Clients send auth, subscribe and unsubscribe; everything
+// else comes from the server.
+const (
+	TypeAuth           = "auth"
+	TypeSubscribe      = "subscribe"
+	TypeUnsubscribe    = "unsubscribe"
+	TypeAuthenticated  = "authenticated"
+	TypeSubscribed     = "subscribed"
+	TypeUnsubscribed   = "unsubscribed"
+	TypeReauthRequired = "reauth_required"
+	TypeEvent          = "event"
+	TypeError          = "error"
+)
+
+// CloseAuthExpired is sent when a connection's token runs out, or it never
+// authenticated, before a fresh token arrived.
+const CloseAuthExpired = 4001
+
+// NotificationsChannel is the caller's own notification feed. Everyone
+// subscribes to it by the same name and only ever sees their own.
+const NotificationsChannel = "notifications"
+
+const maxMessageSize = 4096
+
+// Message is the JSON frame used in both directions.
+type Message struct {
+	Type      string          `json:"type"`
+	Channel   string          `json:"channel,omitempty"`
+	Token     string          `json:"token,omitempty"`
+	Event     string          `json:"event,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// Authenticator checks a token and says who it belongs to and when it
+// expires.
+type Authenticator func(token string) (uuid.UUID, time.Time, error)
+
+func UserChannel(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// HashtagChannel expects a tag that's already been normalized.
+func HashtagChannel(tag string) string {
+	return "hashtag:" + tag
+}
+
+func notificationsKey(userID uuid.UUID) string {
+	return NotificationsChannel + ":" + userID.String()
+}
+
+// Hub tracks websocket connections and the channels they're subscribed to.
+// Publishing never blocks: a connection whose send buffer is full is dropped
+// and the client is expected to reconnect.
+type Hub struct {
+	authenticate Authenticator
+	upgrader     websocket.Upgrader
+
+	// PingPeriod must be shorter than PongWait. ReauthGrace is how long
+	// before expiry clients are asked for a new token, and how long a new
+	// connection without an Authorization header has to send one.
+	PingPeriod  time.Duration
+	PongWait    time.Duration
+	WriteWait   time.Duration
+	ReauthGrace time.Duration
+	SendBuffer  int
+
+	mu       sync.Mutex
+	clients  map[*client]struct{}
+	channels map[string]map[*client]struct{}
+	closed   bool
+}
+
+func NewHub(authenticate Authenticator) *Hub {
+	return &Hub{
+		authenticate: authenticate,
+		PingPeriod:   50 * time.Second,
+		PongWait:     60 * time.Second,
+		WriteWait:    10 * time.Second,
+		ReauthGrace:  time.Minute,
+		SendBuffer:   64,
+		clients:      map[*client]struct{}{},
+		channels:     map[string]map[*client]struct{}{},
+	}
+}
+
+// ServeHTTP upgrades the request. A bearer token in the Authorization header
+// is checked before upgrading; without one the client has ReauthGrace to
+// send an auth message.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var userID uuid.UUID
+	var expiresAt time.Time
+	if header := r.Header.Get("Authorization"); header != "" {
+		var err error
+		userID, expiresAt, err = h.authenticate(strings.TrimPrefix(header, "Bearer "))
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written the error response.
+		return
+	}
+
+	c := &client{
+		hub:  h,
+		conn: conn,
+		send: make(chan []byte, h.SendBuffer),
+		subs: map[string]struct{}{},
+	}
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(h.WriteWait))
+		conn.Close()
+		return
+	}
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+	if userID != uuid.Nil {
+		c.setAuth(userID, expiresAt)
+	} else {
+		c.armDeadline(time.Now().Add(h.ReauthGrace), "authentication required")
+	}
+
+	go c.writePump()
+	go c.readPump()
+}
+
+// Publish sends an event to everyone subscribed to channel.
+func (h *Hub) Publish(channel, event string, data []byte) {
+	h.publish(channel, channel, event, data, nil)
+}
+
+// PublishTo sends an event to the subscribers of channel who are in
+// audience, leaving everyone else unaware of it.
+func (h *Hub) PublishTo(channel, event string, data []byte, audience map[uuid.UUID]bool) {
+	if audience == nil {
+		audience = map[uuid.UUID]bool{}
+	}
+	h.publish(channel, channel, event, data, audience)
+}
+
+// PublishToUser sends an event on userID's notifications channel.
+func (h *Hub) PublishToUser(userID uuid.UUID, event string, data []byte) {
+	h.publish(notificationsKey(userID), NotificationsChannel, event, data, nil)
+}
+
+// publish delivers to every client on key, or only those in audience when it
+// isn't nil.
+func (h *Hub) publish(key, channel, event string, data []byte, audience map[uuid.UUID]bool) {
+	msg, err := json.Marshal(Message{
+		Type:    TypeEvent,
+		Channel: channel,
+		Event:   event,
+		Data:    data,
+	})
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.channels[key] {
+		if audience != nil && !audience[c.currentUser()] {
+			continue
+		}
+		h.sendLocked(c, msg)
+	}
+}
+
+// send queues msg for c, dropping c if it can't keep up.
+func (h *Hub) send(c *client, m Message) {
+	msg, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sendLocked(c, msg)
+}
+
+func (h *Hub) sendLocked(c *client, msg []byte) {
+	if c.closed {
+		return
+	}
+	select {
+	case c.send <- msg:
+	default:
+		h.removeLocked(c)
+	}
+}
+
+func (h *Hub) subscribe(c *client, key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c.closed {
+		return
+	}
+	if h.channels[key] == nil {
+		h.channels[key] = map[*client]struct{}{}
+	}
+	h.channels[key][c] = struct{}{}
+	c.subs[key] = struct{}{}
+}
+
+func (h *Hub) unsubscribe(c *client, key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unsubscribeLocked(c, key)
+}
+
+func (h *Hub) unsubscribeLocked(c *client, key string) {
+	delete(c.subs, key)
+	delete(h.channels[key], c)
+	if len(h.channels[key]) == 0 {
+		delete(h.channels, key)
+	}
+}
+
+// Close disconnects every client and refuses new ones. Websocket connections
+// are hijacked, so http.Server.Shutdown doesn't wait for or close them.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for c := range h.clients {
+		h.removeLocked(c)
+	}
+}
+
+func (h *Hub) remove(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(c)
+}
+
+// removeLocked drops every subscription and closes the send channel, which
+// tells the write pump to say goodbye and close the connection.
+func (h *Hub) removeLocked(c *client) {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	delete(h.clients, c)
+	for key := range c.subs {
+		h.unsubscribeLocked(c, key)
+	}
+	close(c.send)
+	c.stopTimers()
+}
@@ -0,0 +1,324 @@
+package ws
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// fakeAuth hands out tokens for tests without going through JWTs.
+type fakeAuth struct {
+	mu     sync.Mutex
+	tokens map[string]fakeToken
+}
+
+type fakeToken struct {
+	userID    uuid.UUID
+	expiresAt time.Time
+}
+
+func (a *fakeAuth) issue(token string, userID uuid.UUID, expiresIn time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tokens[token] = fakeToken{userID: userID, expiresAt: time.Now().Add(expiresIn)}
+}
+
+func (a *fakeAuth) authenticate(token string) (uuid.UUID, time.Time, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	t, ok := a.tokens[token]
+	if !ok || time.Now().After(t.expiresAt) {
+		return uuid.Nil, time.Time{}, errors.New("invalid token")
+	}
+	return t.userID, t.expiresAt, nil
+}
+
+func newTestServer(t *testing.T) (*Hub, *fakeAuth, *httptest.Server) {
+	a := &fakeAuth{tokens: map[string]fakeToken{}}
+	hub := NewHub(a.authenticate)
+	srv := httptest.NewServer(hub)
+	t.Cleanup(srv.Close)
+	return hub, a, srv
+}
+
+func dial(t *testing.T, srv *httptest.Server, token string) *websocket.Conn {
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), header)
+	if err != nil {
+		t.Fatalf("Error dialing: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, m Message) {
+	if err := conn.WriteJSON(m); err != nil {
+		t.Fatalf("Error writing: %v", err)
+	}
+}
+
+func expect(t *testing.T, conn *websocket.Conn, typ string) Message {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var m Message
+	if err := conn.ReadJSON(&m); err != nil {
+		t.Fatalf("Expected %s message but got error: %v", typ, err)
+	}
+	if m.Type != typ {
+		t.Fatalf("Expected %s message but got: %+v", typ, m)
+	}
+	return m
+}
+
+func TestRejectsInvalidToken(t *testing.T) {
+	_, _, srv := newTestServer(t)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer nope")
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), header)
+	if err == nil {
+		t.Fatalf("Expected dial to fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 but got: %v", resp)
+	}
+}
+
+func TestSubscribeAndUnsubscribe(t *testing.T) {
+	hub, a, srv := newTestServer(t)
+	author := uuid.New()
+	a.issue("token", uuid.New(), time.Hour)
+	conn := dial(t, srv, "token")
+
+	send(t, conn, Message{Type: TypeSubscribe, Channel: UserChannel(author)})
+	expect(t, conn, TypeSubscribed)
+	send(t, conn, Message{Type: TypeSubscribe, Channel: "hashtag:#GoLang"})
+	if m := expect(t, conn, TypeSubscribed); m.Channel != "hashtag:golang" {
+		t.Fatalf("Expected normalized hashtag channel but got: %s", m.Channel)
+	}
+
+	hub.Publish(UserChannel(author), "chirp.created", []byte(`{"id":"1"}`))
+	m := expect(t, conn, TypeEvent)
+	if m.Channel != UserChannel(author) || m.Event != "chirp.created" || string(m.Data) != `{"id":"1"}` {
+		t.Fatalf("Unexpected event: %+v", m)
+	}
+
+	send(t, conn, Message{Type: TypeUnsubscribe, Channel: UserChannel(author)})
+	expect(t, conn, TypeUnsubscribed)
+
+	hub.Publish(UserChannel(author), "chirp.created", []byte(`{"id":"2"}`))
+	hub.Publish(HashtagChannel("golang"), "chirp.created", []byte(`{"id":"3"}`))
+	if m := expect(t, conn, TypeEvent); string(m.Data) != `{"id":"3"}` {
+		t.Fatalf("Expected only the hashtag event after unsubscribing but got: %+v", m)
+	}
+}
+
+func TestPublishToAudience(t *testing.T) {
+	hub, a, srv := newTestServer(t)
+	author := uuid.New()
+	follower := uuid.New()
+	a.issue("follower", follower, time.Hour)
+	a.issue("stranger", uuid.New(), time.Hour)
+	followerConn := dial(t, srv, "follower")
+	strangerConn := dial(t, srv, "stranger")
+	for _, conn := range []*websocket.Conn{followerConn, strangerConn} {
+		send(t, conn, Message{Type: TypeSubscribe, Channel: UserChannel(author)})
+		expect(t, conn, TypeSubscribed)
+	}
+
+	hub.PublishTo(UserChannel(author), "chirp.created", []byte(`{"id":"1"}`), map[uuid.UUID]bool{author: true, follower: true})
+	hub.Publish(UserChannel(author), "chirp.created", []byte(`{"id":"2"}`))
+
+	if m := expect(t, followerConn, TypeEvent); string(m.Data) != `{"id":"1"}` {
+		t.Fatalf("Expected the restricted event first but got: %+v", m)
+	}
+	if m := expect(t, strangerConn, TypeEvent); string(m.Data) != `{"id":"2"}` {
+		t.Fatalf("Expected only the public event outside the audience but got: %+v", m)
+	}
+}
+
+func TestUnknownChannel(t *testing.T) {
+	_, a, srv := newTestServer(t)
+	a.issue("token", uuid.New(), time.Hour)
+	conn := dial(t, srv, "token")
+
+	send(t, conn, Message{Type: TypeSubscribe, Channel: "user:not-a-uuid"})
+	expect(t, conn, TypeError)
+	send(t, conn, Message{Type: TypeSubscribe, Channel: "everything"})
+	expect(t, conn, TypeError)
+}
+
+func TestNotificationsOnlyReachTheirOwner(t *testing.T) {
+	hub, a, srv := newTestServer(t)
+	alice, bob := uuid.New(), uuid.New()
+	a.issue("alice", alice, time.Hour)
+	a.issue("bob", bob, time.Hour)
+	aliceConn := dial(t, srv, "alice")
+	bobConn := dial(t, srv, "bob")
+
+	for _, conn := range []*websocket.Conn{aliceConn, bobConn} {
+		send(t, conn, Message{Type: TypeSubscribe, Channel: NotificationsChannel})
+		expect(t, conn, TypeSubscribed)
+	}
+
+	hub.PublishToUser(bob, "notification", []byte(`{"for":"bob"}`))
+	hub.PublishToUser(alice, "notification", []byte(`{"for":"alice"}`))
+
+	if m := expect(t, aliceConn, TypeEvent); string(m.Data) != `{"for":"alice"}` {
+		t.Fatalf("Alice got someone else's notification: %+v", m)
+	}
+	if m := expect(t, bobConn, TypeEvent); string(m.Data) != `{"for":"bob"}` {
+		t.Fatalf("Bob got someone else's notification: %+v", m)
+	}
+}
+
+func TestAuthMessageWithoutHeader(t *testing.T) {
+	_, a, srv := newTestServer(t)
+	a.issue("token", uuid.New(), time.Hour)
+	conn := dial(t, srv, "")
+
+	send(t, conn, Message{Type: TypeSubscribe, Channel: NotificationsChannel})
+	expect(t, conn, TypeError)
+
+	send(t, conn, Message{Type: TypeAuth, Token: "token"})
+	expect(t, conn, TypeAuthenticated)
+	send(t, conn, Message{Type: TypeSubscribe, Channel: NotificationsChannel})
+	expect(t, conn, TypeSubscribed)
+}
+
+func TestClosesUnauthenticatedConnection(t *testing.T) {
+	hub, _, srv := newTestServer(t)
+	hub.ReauthGrace = 100 * time.Millisecond
+	conn := dial(t, srv, "")
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, CloseAuthExpired) {
+		t.Fatalf("Expected close code %d but got: %v", CloseAuthExpired, err)
+	}
+}
+
+func TestReauthenticateBeforeExpiry(t *testing.T) {
+	hub, a, srv := newTestServer(t)
+	hub.ReauthGrace = 900 * time.Millisecond
+	userID := uuid.New()
+	a.issue("old", userID, time.Second)
+	a.issue("new", userID, time.Hour)
+	conn := dial(t, srv, "old")
+
+	send(t, conn, Message{Type: TypeSubscribe, Channel: UserChannel(userID)})
+	expect(t, conn, TypeSubscribed)
+
+	expect(t, conn, TypeReauthRequired)
+	send(t, conn, Message{Type: TypeAuth, Token: "new"})
+	expect(t, conn, TypeAuthenticated)
+
+	// Outlive the old token; the connection and its subscriptions stay up.
+	time.Sleep(time.Second)
+	hub.Publish(UserChannel(userID), "chirp.created", []byte(`{}`))
+	expect(t, conn, TypeEvent)
+}
+
+func TestReauthenticateRejectsOtherUser(t *testing.T) {
+	_, a, srv := newTestServer(t)
+	a.issue("alice", uuid.New(), time.Hour)
+	a.issue("bob", uuid.New(), time.Hour)
+	conn := dial(t, srv, "alice")
+
+	send(t, conn, Message{Type: TypeAuth, Token: "bob"})
+	expect(t, conn, TypeError)
+}
+
+func TestClosesWhenTokenExpires(t *testing.T) {
+	hub, a, srv := newTestServer(t)
+	hub.ReauthGrace = 500 * time.Millisecond
+	a.issue("token", uuid.New(), 700*time.Millisecond)
+	conn := dial(t, srv, "token")
+
+	expect(t, conn, TypeReauthRequired)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, CloseAuthExpired) {
+		t.Fatalf("Expected close code %d but got: %v", CloseAuthExpired, err)
+	}
+}
+
+func TestPingKeepalive(t *testing.T) {
+	hub, a, srv := newTestServer(t)
+	hub.PingPeriod = 50 * time.Millisecond
+	a.issue("token", uuid.New(), time.Hour)
+	conn := dial(t, srv, "token")
+
+	pinged := make(chan struct{}, 1)
+	conn.SetPingHandler(func(data string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	// Pings are only processed while reading.
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-pinged:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected a ping from the server")
+	}
+}
+
+func TestSlowClientIsDropped(t *testing.T) {
+	hub, a, srv := newTestServer(t)
+	hub.SendBuffer = 1
+	author := uuid.New()
+	a.issue("token", uuid.New(), time.Hour)
+	conn := dial(t, srv, "token")
+
+	send(t, conn, Message{Type: TypeSubscribe, Channel: UserChannel(author)})
+	expect(t, conn, TypeSubscribed)
+
+	// The client isn't reading, so the buffer fills and the hub gives up on
+	// it instead of blocking the publisher.
+	for i := 0; i < 1000; i++ {
+		hub.Publish(UserChannel(author), "chirp.created", []byte(`{}`))
+	}
+
+	hub.mu.Lock()
+	subscribers := len(hub.channels[UserChannel(author)])
+	hub.mu.Unlock()
+	if subscribers != 0 {
+		t.Fatalf("Expected slow client to be dropped but it has %d subscriptions", subscribers)
+	}
+}
+
+func TestCloseDisconnectsClients(t *testing.T) {
+	hub, a, srv := newTestServer(t)
+	a.issue("token", uuid.New(), time.Hour)
+	conn := dial(t, srv, "token")
+
+	// Make sure the hub has registered the connection before closing.
+	send(t, conn, Message{Type: TypeSubscribe, Channel: NotificationsChannel})
+	expect(t, conn, TypeSubscribed)
+
+	hub.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived) {
+		t.Fatalf("Expected the connection to be closed but got: %v", err)
+	}
+}
@@ -1,22 +1,48 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/RobertGolawski/Chirpy/internal/auth"
 	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/jobs"
+	"github.com/RobertGolawski/Chirpy/internal/mail"
+	"github.com/RobertGolawski/Chirpy/internal/media"
+	"github.com/RobertGolawski/Chirpy/internal/notifications"
+	"github.com/RobertGolawski/Chirpy/internal/stream"
+	"github.com/RobertGolawski/Chirpy/internal/ws"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
+	db             *sql.DB
 	queries        *database.Queries
+	notifier       *notifications.Notifier
+	broadcaster    *stream.Broadcaster
+	hub            *ws.Hub
+	jobs           *jobs.Runner
+	blobs          media.BlobStore
 	platform       string
-	secret         string
+	keys           *auth.KeySet
+	signingAlg     string
+	secrets        *auth.SecretBox
+	signingKeyBox  *auth.SecretBox
+	mailer         mail.Mailer
+	appURL         string
 	api            string
 }
 
@@ -26,40 +52,215 @@ func main() {
 	p := os.Getenv("PLATFORM")
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
-		fmt.Sprintf("An error popped up: %v", err)
+		log.Printf("An error popped up: %v", err)
 		return
 	}
-	s := os.Getenv("SECRET")
 	a := os.Getenv("POLKA_KEY")
 	var cfg apiConfig
 	cfg.platform = p
 	cfg.api = a
+	cfg.db = db
 	dbQueries := database.New(db)
 	cfg.queries = dbQueries
-	cfg.secret = s
+	cfg.keys = auth.NewKeySet()
+	cfg.signingAlg = os.Getenv("JWT_SIGNING_ALG")
+	if cfg.signingAlg == "" {
+		cfg.signingAlg = auth.AlgEdDSA
+	}
+	if cfg.signingAlg != auth.AlgEdDSA && cfg.signingAlg != auth.AlgRS256 {
+		log.Printf("Unsupported JWT_SIGNING_ALG %q", cfg.signingAlg)
+		return
+	}
+	cfg.signingKeyBox, err = secretBoxFromEnv("SIGNING_KEY_ENCRYPTION_KEY")
+	if err != nil {
+		log.Printf("%v", err)
+		return
+	}
+	if cfg.signingKeyBox == nil {
+		log.Printf("SIGNING_KEY_ENCRYPTION_KEY must be set")
+		return
+	}
+	// Logins need a signing key, so don't take requests until there is one.
+	if err := cfg.refreshSigningKeys(context.Background()); err != nil {
+		log.Printf("Error loading signing keys: %v", err)
+		return
+	}
+	// 2FA stays unavailable until there's a key to encrypt TOTP secrets with.
+	cfg.secrets, err = secretBoxFromEnv("TOTP_ENCRYPTION_KEY")
+	if err != nil {
+		log.Printf("%v", err)
+		return
+	}
+	cfg.mailer, err = newMailer()
+	if err != nil {
+		log.Printf("Error setting up the mailer: %v", err)
+		return
+	}
+	cfg.appURL = os.Getenv("APP_URL")
+	if cfg.appURL == "" {
+		cfg.appURL = "http://localhost:8080"
+	}
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "media"
+	}
+	cfg.blobs, err = media.NewLocalStore(mediaDir)
+	if err != nil {
+		log.Printf("Error setting up media storage: %v", err)
+		return
+	}
+	cfg.broadcaster = stream.NewBroadcaster(1000, 64)
+	cfg.hub = ws.NewHub(func(token string) (uuid.UUID, time.Time, error) {
+		return auth.ValidateJWTWithExpiry(token, cfg.keys)
+	})
+	cfg.notifier = notifications.NewNotifier(db, dbQueries, 1024)
+	cfg.notifier.OnStored(cfg.pushNotification)
+	cfg.notifier.Start(2)
+	defer cfg.notifier.Close()
+	cfg.jobs = jobs.NewRunner()
+	cfg.jobs.Every("trash purge", trashPurgeInterval, cfg.purgeTrash)
+	cfg.jobs.Every("scheduled chirps", scheduledChirpInterval, cfg.publishScheduledChirps)
+	cfg.jobs.Every("poll closing", pollClosingInterval, cfg.closePolls)
+	cfg.jobs.Every("ephemeral chirps", ephemeralReapInterval, cfg.reapExpiredChirps)
+	cfg.jobs.Every("signing keys", signingKeyRefreshInterval, cfg.refreshSigningKeys)
+	cfg.jobs.Start()
+	defer cfg.jobs.Stop()
 	var server = http.NewServeMux()
 	server.Handle("/app/", cfg.middlewareMetrics(http.StripPrefix("/app/", http.FileServer(http.Dir(".")))))
 	server.Handle("./app/assets/logo.png", http.StripPrefix("/app/", http.FileServer(http.Dir("./assets/logo.png"))))
 	server.HandleFunc("GET /api/healthz", cfg.getHealthz)
+	server.HandleFunc("GET /.well-known/jwks.json", cfg.getJWKS)
 	server.HandleFunc("GET /admin/metrics", cfg.handleMetrics)
 	server.HandleFunc("POST /admin/reset", cfg.resetAllUSers)
 	// server.HandleFunc("POST /api/validate_chirp", cfg.validate_chirp)
 	server.HandleFunc("POST /api/chirps", cfg.send_chirp)
 	server.HandleFunc("GET /api/chirps", cfg.get_chirps)
+	server.HandleFunc("GET /api/chirps/search", cfg.searchChirps)
 	server.HandleFunc("GET /api/chirps/{id}", cfg.get_chirp_by_id)
+	server.HandleFunc("GET /api/chirps/{id}/thread", cfg.getChirpThread)
+	server.HandleFunc("PUT /api/chirps/{id}", cfg.editChirpByID)
+	server.HandleFunc("GET /api/chirps/{id}/revisions", cfg.getChirpRevisions)
+	server.HandleFunc("POST /api/chirps/{id}/poll/vote", cfg.votePoll)
+	server.HandleFunc("POST /api/chirps/{id}/restore", cfg.restoreChirpByID)
+	server.HandleFunc("POST /api/chirps/{id}/bookmark", cfg.bookmarkChirp)
+	server.HandleFunc("DELETE /api/chirps/{id}/bookmark", cfg.unbookmarkChirp)
+	server.HandleFunc("POST /api/chirps/{id}/pin", cfg.pinChirpByID)
+	server.HandleFunc("DELETE /api/chirps/{id}/pin", cfg.unpinChirpByID)
+	server.HandleFunc("PUT /api/chirps/{id}/sensitivity", cfg.setChirpSensitivity)
+	server.HandleFunc("GET /api/me/trash", cfg.getTrash)
+	server.HandleFunc("GET /api/me/bookmarks", cfg.getBookmarks)
+	server.HandleFunc("GET /api/me/sessions", cfg.getSessions)
+	server.HandleFunc("POST /api/me/2fa/setup", cfg.setupTwoFactor)
+	server.HandleFunc("POST /api/me/2fa/confirm", cfg.confirmTwoFactor)
+	server.HandleFunc("DELETE /api/me/2fa", cfg.disableTwoFactor)
+	server.HandleFunc("DELETE /api/me/sessions", cfg.revokeAllSessions)
+	server.HandleFunc("DELETE /api/me/sessions/{id}", cfg.revokeSession)
+	server.HandleFunc("POST /api/media", cfg.uploadMedia)
+	server.HandleFunc("GET /api/media/{id}", cfg.getMediaFile)
+	server.HandleFunc("GET /api/media/{id}/thumbnail", cfg.getMediaThumbnail)
+	server.HandleFunc("POST /api/drafts", cfg.createDraft)
+	server.HandleFunc("GET /api/drafts", cfg.getDrafts)
+	server.HandleFunc("GET /api/drafts/{id}", cfg.getDraft)
+	server.HandleFunc("PUT /api/drafts/{id}", cfg.updateDraft)
+	server.HandleFunc("DELETE /api/drafts/{id}", cfg.deleteDraft)
+	server.HandleFunc("POST /api/drafts/{id}/publish", cfg.publishDraftByID)
 	// server.HandleFunc("GET /api/chirps/{author_id}", cfg.get_chirps_for_user)
 	server.HandleFunc("POST /api/users", cfg.createUserRequest)
 	server.HandleFunc("POST /api/login", cfg.logInRequest)
+	server.HandleFunc("POST /api/login/2fa", cfg.logInSecondFactor)
+	server.HandleFunc("POST /api/password/forgot", cfg.forgotPassword)
+	server.HandleFunc("POST /api/password/reset", cfg.resetPassword)
 	server.HandleFunc("POST /api/refresh", cfg.refreshJWT)
 	server.HandleFunc("POST /api/revoke", cfg.revokeRefreshToken)
 	server.HandleFunc("PUT /api/users", cfg.updateUserDetails)
+	server.HandleFunc("GET /api/users/{handle}", cfg.getUserProfile)
 	server.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.deleteChirpByID)
 	server.HandleFunc("POST /api/polka/webhooks", cfg.upgradeUserToRed)
+	server.HandleFunc("POST /api/users/{id}/follow", cfg.followUser)
+	server.HandleFunc("DELETE /api/users/{id}/follow", cfg.unfollowUser)
+	server.HandleFunc("GET /api/users/{id}/followers", cfg.getFollowers)
+	server.HandleFunc("GET /api/users/{id}/following", cfg.getFollowing)
+	server.HandleFunc("GET /api/timeline", cfg.getTimeline)
+	server.HandleFunc("POST /api/chirps/{id}/like", cfg.likeChirp)
+	server.HandleFunc("DELETE /api/chirps/{id}/like", cfg.unlikeChirp)
+	server.HandleFunc("GET /api/users/{id}/likes", cfg.getUserLikes)
+	server.HandleFunc("POST /api/chirps/{id}/rechirp", cfg.rechirp)
+	server.HandleFunc("DELETE /api/chirps/{id}/rechirp", cfg.undoRechirp)
+	server.HandleFunc("GET /api/hashtags/trending", cfg.getTrendingHashtags)
+	server.HandleFunc("GET /api/hashtags/{tag}/chirps", cfg.getHashtagChirps)
+	server.HandleFunc("GET /api/notifications", cfg.getNotifications)
+	server.HandleFunc("POST /api/notifications/read", cfg.markNotificationsRead)
+	server.HandleFunc("GET /api/stream", cfg.streamChirps)
+	server.Handle("GET /api/ws", cfg.hub)
 	var serverStruct = http.Server{
 		Handler: server,
 		Addr:    ":8080",
 	}
+	// Streams and websockets never finish on their own, so close them when
+	// shutdown starts instead of waiting out the timeout.
+	serverStruct.RegisterOnShutdown(cfg.broadcaster.Close)
+	serverStruct.RegisterOnShutdown(cfg.hub.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		if err := serverStruct.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Server error: %v", err)
+			stop()
+		}
+	}()
 
-	serverStruct.ListenAndServe()
+	<-ctx.Done()
+	log.Println("Shutting down")
+	// In-flight requests get a few seconds to finish. The deferred calls
+	// above then stop the background jobs, so no scheduled chirp is left
+	// half-published, and flush the notification queue.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := serverStruct.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error during shutdown: %v", err)
+	}
+}
 
+// newMailer picks how email goes out from MAILER: "smtp" sends through
+// SMTP_ADDR, "memory" keeps it in memory, and the default writes it to .eml
+// files in MAIL_OUTBOX_DIR for local development.
+func newMailer() (mail.Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	switch os.Getenv("MAILER") {
+	case "smtp":
+		return mail.NewSMTPMailer(os.Getenv("SMTP_ADDR"), from, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+	case "memory":
+		return mail.NewMemoryMailer(), nil
+	case "", "file":
+		dir := os.Getenv("MAIL_OUTBOX_DIR")
+		if dir == "" {
+			dir = "outbox"
+		}
+		return mail.NewFileOutbox(dir, from)
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q", os.Getenv("MAILER"))
+	}
+}
+
+// secretBoxFromEnv builds a SecretBox from the base64 encoded key in the
+// environment variable name, or returns nil if it isn't set.
+func secretBoxFromEnv(name string) (*auth.SecretBox, error) {
+	k := os.Getenv(name)
+	if k == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(k)
+	if err == nil {
+		var box *auth.SecretBox
+		box, err = auth.NewSecretBox(key)
+		if err == nil {
+			return box, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s, expected 32 base64 encoded bytes: %w", name, err)
 }
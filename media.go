@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/media"
+	"github.com/google/uuid"
+)
+
+const (
+	maxUploadBytes = 10 << 20
+	maxChirpMedia  = 4
+)
+
+type mediaResponse struct {
+	ID           string `json:"id"`
+	ContentType  string `json:"content_type"`
+	Width        int32  `json:"width"`
+	Height       int32  `json:"height"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+func newMediaResponse(m database.Medium) mediaResponse {
+	return mediaResponse{
+		ID:           m.ID.String(),
+		ContentType:  m.ContentType,
+		Width:        m.Width,
+		Height:       m.Height,
+		URL:          "/api/media/" + m.ID.String(),
+		ThumbnailURL: "/api/media/" + m.ID.String() + "/thumbnail",
+	}
+}
+
+// uploadMedia takes a multipart upload in the "file" field. The file's type
+// comes from its magic bytes, not the client, and metadata like EXIF GPS
+// coordinates is stripped before anything is stored.
+func (cfg *apiConfig) uploadMedia(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in media upload: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes+1<<20)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			resp := map[string]string{"error": "File is too large"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with reading the upload"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxUploadBytes+1))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with reading the upload"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if len(data) > maxUploadBytes {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		resp := map[string]string{"error": "File is too large"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	img, err := media.Process(data)
+	if errors.Is(err, media.ErrUnsupportedType) {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		resp := map[string]string{"error": "Only JPEG, PNG and GIF images are supported"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with processing the image"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	blobKey, err := cfg.blobs.Put(r.Context(), img.Data)
+	if err != nil {
+		log.Printf("Error storing media: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during upload"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	thumbnailKey, err := cfg.blobs.Put(r.Context(), img.Thumbnail)
+	if err != nil {
+		log.Printf("Error storing thumbnail: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during upload"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	m, err := cfg.queries.CreateMedia(r.Context(), database.CreateMediaParams{
+		UserID:               userID,
+		ContentType:          img.ContentType,
+		BlobKey:              blobKey,
+		SizeBytes:            int32(len(img.Data)),
+		Width:                int32(img.Width),
+		Height:               int32(img.Height),
+		ThumbnailKey:         thumbnailKey,
+		ThumbnailContentType: img.ThumbnailType,
+	})
+	if err != nil {
+		log.Printf("Error saving media: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during upload"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	jsonResp, err := json.Marshal(newMediaResponse(m))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during marshalling"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonResp)
+}
+
+func (cfg *apiConfig) getMediaFile(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, false)
+}
+
+func (cfg *apiConfig) getMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, true)
+}
+
+// serveMedia streams a stored image to anyone who can see a chirp it's
+// attached to, and to its uploader. Blobs never change under a given media
+// ID, so media on a public chirp that won't expire may be cached by anyone
+// forever; everything else is kept out of shared caches and rechecked, since
+// who can see it changes as chirps are deleted or expire.
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	path := r.PathValue("id")
+	mediaID, err := uuid.Parse(path)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	m, err := cfg.queries.GetMedia(r.Context(), mediaID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	viewer := cfg.viewerID(r)
+	access, err := cfg.queries.GetMediaAccess(r.Context(), database.GetMediaAccessParams{
+		MediaID:  mediaID,
+		ViewerID: viewer,
+	})
+	if err != nil {
+		log.Printf("Error checking media access: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	isUploader := viewer.Valid && viewer.UUID == m.UserID
+	if !access.Visible && !isUploader {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	key, contentType := m.BlobKey, m.ContentType
+	if thumbnail {
+		key, contentType = m.ThumbnailKey, m.ThumbnailContentType
+	}
+
+	blob, err := cfg.blobs.Open(r.Context(), key)
+	if err != nil {
+		log.Printf("Error opening media blob %s: %v", key, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if access.Public {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, blob)
+}
+
+// checkMediaIDs makes sure a chirp references at most maxChirpMedia
+// distinct uploads, all belonging to its author. It returns the status and
+// message to fail with, or 0 if they're fine.
+func (cfg *apiConfig) checkMediaIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (int, string) {
+	if len(ids) == 0 {
+		return 0, ""
+	}
+	if len(ids) > maxChirpMedia {
+		return http.StatusBadRequest, "A chirp can have at most 4 media attachments"
+	}
+	for i, id := range ids {
+		if slices.Contains(ids[:i], id) {
+			return http.StatusBadRequest, "Media attachments must be distinct"
+		}
+	}
+
+	owned, err := cfg.queries.CountUserMedia(ctx, database.CountUserMediaParams{
+		UserID: userID,
+		Ids:    ids,
+	})
+	if err != nil {
+		log.Printf("Error checking media: %v", err)
+		return http.StatusInternalServerError, "Something went wrong during retrieval"
+	}
+	if owned != int64(len(ids)) {
+		return http.StatusNotFound, "Media not found"
+	}
+	return 0, ""
+}
+
+func linkChirpMedia(ctx context.Context, qtx *database.Queries, chirpID uuid.UUID, ids []uuid.UUID) error {
+	for i, id := range ids {
+		err := qtx.AddChirpMedia(ctx, database.AddChirpMediaParams{
+			ChirpID:  chirpID,
+			MediaID:  id,
+			Position: int32(i),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
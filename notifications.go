@@ -0,0 +1,189 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/notifications"
+	"github.com/RobertGolawski/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type notificationResponse struct {
+	ID            string    `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Kind          string    `json:"kind"`
+	Message       string    `json:"message"`
+	ChirpID       string    `json:"chirp_id,omitempty"`
+	ActorCount    int32     `json:"actor_count"`
+	LatestActorID string    `json:"latest_actor_id,omitempty"`
+	Read          bool      `json:"read"`
+}
+
+type notificationPage struct {
+	Notifications []notificationResponse `json:"notifications"`
+	NextCursor    string                 `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) getNotifications(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in notifications: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Invalid pagination parameters"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	params := database.GetNotificationsParams{
+		UserID:     userID,
+		UnreadOnly: r.URL.Query().Get("unread") == "true",
+		PageLimit:  int32(limit + 1),
+	}
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		cursor, err := pagination.DecodeCursor(c)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			resp := map[string]string{"error": "Invalid pagination parameters"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+		params.CursorUpdatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	ns, err := cfg.queries.GetNotifications(r.Context(), params)
+	if err != nil {
+		log.Printf("Error fetching notifications: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	page := notificationPage{Notifications: []notificationResponse{}}
+	if len(ns) > limit {
+		ns = ns[:limit]
+		last := ns[len(ns)-1]
+		page.NextCursor = pagination.EncodeCursor(last.UpdatedAt, last.ID)
+	}
+	for _, n := range ns {
+		resp := notificationResponse{
+			ID:         n.ID.String(),
+			CreatedAt:  n.CreatedAt,
+			UpdatedAt:  n.UpdatedAt,
+			Kind:       n.Kind,
+			Message:    notifications.Message(n.Kind, n.ActorCount),
+			ActorCount: n.ActorCount,
+			Read:       n.ReadAt.Valid,
+		}
+		if n.ChirpID.Valid {
+			resp.ChirpID = n.ChirpID.UUID.String()
+		}
+		if n.LatestActorID.Valid {
+			resp.LatestActorID = n.LatestActorID.UUID.String()
+		}
+		page.Notifications = append(page.Notifications, resp)
+	}
+
+	jsonResp, err := json.Marshal(page)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during response generation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
+
+// markNotificationsRead marks the listed notifications as read, or every
+// unread one when all is set.
+func (cfg *apiConfig) markNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		IDs []uuid.UUID `json:"ids"`
+		All bool        `json:"all"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in notifications: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		log.Printf("Error decoding JSON: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with parsing JSON"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if params.All {
+		err = cfg.queries.MarkAllNotificationsRead(r.Context(), userID)
+	} else {
+		err = cfg.queries.MarkNotificationsRead(r.Context(), database.MarkNotificationsReadParams{
+			UserID: userID,
+			Ids:    params.IDs,
+		})
+	}
+	if err != nil {
+		log.Printf("Error marking notifications read: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong marking notifications read"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+type chirpPage struct {
+	Chirps     []chirpResponse `json:"chirps"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// pageParams holds the limit and cursor query params. Requests that send
+// neither aren't paginated, and get a bare array, unless the handler sets a
+// default page as the chirp listings and hashtag pages do.
+type pageParams struct {
+	paginated       bool
+	limit           int
+	cursorCreatedAt sql.NullTime
+	cursorID        uuid.NullUUID
+}
+
+func parsePageParams(r *http.Request) (pageParams, error) {
+	p := pageParams{}
+	l := r.URL.Query().Get("limit")
+	c := r.URL.Query().Get("cursor")
+	if l == "" && c == "" {
+		return p, nil
+	}
+
+	p.paginated = true
+	limit, err := parseLimit(r)
+	if err != nil {
+		return p, err
+	}
+	p.limit = limit
+
+	if c != "" {
+		cursor, err := pagination.DecodeCursor(c)
+		if err != nil {
+			return p, err
+		}
+		p.cursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		p.cursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	return p, nil
+}
+
+// parseLimit reads the limit query param, falling back to the default page
+// size and capping it at maxPageLimit.
+func parseLimit(r *http.Request) (int, error) {
+	l := r.URL.Query().Get("limit")
+	if l == "" {
+		return defaultPageLimit, nil
+	}
+	limit, err := strconv.Atoi(l)
+	if err != nil || limit < 1 {
+		return 0, errors.New("invalid limit")
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return limit, nil
+}
+
+// queryLimit asks for one extra row so we know whether another page exists.
+func (p pageParams) queryLimit() sql.NullInt32 {
+	if !p.paginated {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(p.limit + 1), Valid: true}
+}
+
+// trim drops the lookahead row and returns the cursor for the next page, if
+// there is one.
+func (p pageParams) trim(cs []database.Chirp) ([]database.Chirp, string) {
+	if !p.paginated || len(cs) <= p.limit {
+		return cs, ""
+	}
+	cs = cs[:p.limit]
+	last := cs[len(cs)-1]
+	return cs, pagination.EncodeCursor(last.CreatedAt, last.ID)
+}
+
+// trimFeed is trim for feeds that mix chirps and rechirps.
+func (p pageParams) trimFeed(entries []feedEntry) ([]feedEntry, string) {
+	if !p.paginated || len(entries) <= p.limit {
+		return entries, ""
+	}
+	entries = entries[:p.limit]
+	last := entries[len(entries)-1]
+	return entries, pagination.EncodeCursor(last.at, last.id)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/mail"
+	"github.com/google/uuid"
+)
+
+// Password resets go by email: /api/password/forgot mails a link with a
+// single-use token, and /api/password/reset swaps that token for a new
+// password. A reset logs the user out everywhere, since whoever knew the old
+// password may have sessions of their own. 2FA still applies at the next
+// login.
+
+const (
+	passwordResetTTL = time.Hour
+	// passwordResetCooldown stops /forgot being used to flood someone's inbox.
+	passwordResetCooldown = time.Minute
+	passwordResetTimeout  = 30 * time.Second
+)
+
+// forgotPassword always answers 202 straight away whether or not the email
+// belongs to anyone. Looking the user up, issuing the token and sending the
+// mail all happen in the background, so neither the response nor how long
+// it takes gives away who has an account.
+func (cfg *apiConfig) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		log.Printf("Error decoding JSON: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with parsing JSON"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), passwordResetTimeout)
+		defer cancel()
+		if err := cfg.startPasswordReset(ctx, params.Email); err != nil {
+			log.Printf("Error starting password reset: %v", err)
+		}
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// startPasswordReset issues a reset token for the user with email, if there
+// is one and they haven't just been sent one, and mails it to them. It runs
+// after forgotPassword has answered, so errors are only logged.
+func (cfg *apiConfig) startPasswordReset(ctx context.Context, email string) error {
+	u, err := cfg.queries.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Unknown addresses are ignored, as far as the caller can tell.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.queries.DeleteExpiredPasswordResetTokens(ctx); err != nil {
+		return err
+	}
+	recent, err := cfg.queries.CountRecentPasswordResetTokens(ctx, database.CountRecentPasswordResetTokensParams{
+		UserID:        u.ID,
+		WindowSeconds: passwordResetCooldown.Seconds(),
+	})
+	if err != nil {
+		return err
+	}
+	if recent > 0 {
+		return nil
+	}
+
+	token, err := auth.MakeOpaqueToken()
+	if err != nil {
+		return err
+	}
+	err = cfg.queries.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash:  auth.HashToken(token),
+		UserID:     u.ID,
+		TtlSeconds: passwordResetTTL.Seconds(),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := cfg.appURL + "/reset-password?token=" + url.QueryEscape(token)
+	msg := mail.Message{
+		To:      u.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your Chirpy account.\n\n"+
+			"To choose a new one, open this link within %d minutes:\n\n%s\n\n"+
+			"If it wasn't you, you can ignore this email and your password will stay the same.\n",
+			int(passwordResetTTL.Minutes()), link),
+	}
+	return cfg.mailer.Send(ctx, msg)
+}
+
+func (cfg *apiConfig) resetPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		log.Printf("Error decoding JSON: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with parsing JSON"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if params.Password == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Password can't be empty"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	hp, err := auth.HashPassword(params.Password)
+	if err != nil {
+		log.Printf("Error creating the hash: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with hashing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	ok, err := cfg.finishPasswordReset(r.Context(), params.Token, hp)
+	if err != nil {
+		log.Printf("Error resetting password: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during password reset"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Invalid or expired reset token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// finishPasswordReset uses up token and sets the user's password to
+// hashedPassword, revoking every refresh token they have. It reports false
+// if the token isn't valid.
+func (cfg *apiConfig) finishPasswordReset(ctx context.Context, token, hashedPassword string) (bool, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	userID, err := qtx.UsePasswordResetToken(ctx, auth.HashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = qtx.UpdatePassword(ctx, database.UpdatePasswordParams{
+		HashedPassword: hashedPassword,
+		ID:             userID,
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := qtx.InvalidatePasswordResetTokens(ctx, userID); err != nil {
+		return false, err
+	}
+	if err := qtx.RevokeUserRefreshTokens(ctx, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// maxPinnedChirps is how many of their own chirps a user can pin to the top
+// of their profile at once.
+const maxPinnedChirps = 3
+
+var errTooManyPins = errors.New("too many pinned chirps")
+
+func (cfg *apiConfig) pinChirpByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	chirpID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in pin: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	c, err := cfg.getVisibleChirp(r.Context(), chirpID, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if userID != c.UserID.UUID {
+		w.WriteHeader(http.StatusForbidden)
+		resp := map[string]string{"error": "Forbidden"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	err = cfg.pinChirp(r.Context(), userID, chirpID)
+	if errors.Is(err, errTooManyPins) {
+		w.WriteHeader(http.StatusConflict)
+		resp := map[string]string{"error": "You can pin at most 3 chirps"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if err != nil {
+		log.Printf("Error pinning chirp: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during pin"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pinChirp pins one of the user's chirps unless they're already at the limit.
+// The user's row is locked while counting so concurrent pins can't overshoot
+// it. Pinning a chirp that's already pinned does nothing.
+func (cfg *apiConfig) pinChirp(ctx context.Context, userID, chirpID uuid.UUID) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	if err := qtx.LockUserPins(ctx, userID); err != nil {
+		return err
+	}
+	pinned, err := qtx.CountPinnedChirps(ctx, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		return err
+	}
+
+	n, err := qtx.PinChirp(ctx, database.PinChirpParams{
+		ID:     chirpID,
+		UserID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if n > 0 && pinned >= maxPinnedChirps {
+		return errTooManyPins
+	}
+	return tx.Commit()
+}
+
+func (cfg *apiConfig) unpinChirpByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	chirpID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in unpin: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	err = cfg.queries.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		ID:     chirpID,
+		UserID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		log.Printf("Error unpinning chirp: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during unpin"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// withPinnedFirst puts the author's pinned chirps at the top of the first
+// page of their feed and drops them from where they'd otherwise appear, so
+// no chirp shows up twice. Later pages only lose the pinned chirps. Their
+// rechirps of other people's chirps are left where they are.
+func withPinnedFirst(entries []feedEntry, pinned []database.Chirp, firstPage bool) []feedEntry {
+	if len(pinned) == 0 {
+		return entries
+	}
+	isPinned := map[uuid.UUID]bool{}
+	for _, p := range pinned {
+		isPinned[p.ID] = true
+	}
+
+	out := make([]feedEntry, 0, len(entries)+len(pinned))
+	if firstPage {
+		for _, p := range pinned {
+			out = append(out, feedEntry{chirp: p, at: p.CreatedAt, id: p.ID})
+		}
+	}
+	for _, e := range entries {
+		if e.rechirpedBy.Valid || !isPinned[e.chirp.ID] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestWithPinnedFirstKeepsRechirps(t *testing.T) {
+	now := time.Now()
+	pinned := database.Chirp{ID: uuid.New(), CreatedAt: now.Add(-time.Hour), PinnedAt: sql.NullTime{Time: now, Valid: true}}
+	other := database.Chirp{ID: uuid.New(), CreatedAt: now}
+	sharer := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+
+	entries := []feedEntry{
+		{chirp: other, at: other.CreatedAt, id: other.ID},
+		{chirp: pinned, at: now, id: uuid.New(), rechirpedBy: sharer},
+		{chirp: pinned, at: pinned.CreatedAt, id: pinned.ID},
+	}
+
+	got := withPinnedFirst(entries, []database.Chirp{pinned}, true)
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 entries but got %d", len(got))
+	}
+	if got[0].chirp.ID != pinned.ID || got[0].rechirpedBy.Valid {
+		t.Fatalf("Expected the pinned chirp first")
+	}
+	if got[1].chirp.ID != other.ID || !got[2].rechirpedBy.Valid {
+		t.Fatalf("Expected the rest of the feed in order with the rechirp kept")
+	}
+
+	got = withPinnedFirst(entries, []database.Chirp{pinned}, false)
+	if len(got) != 2 || got[0].chirp.ID != other.ID || !got[1].rechirpedBy.Valid {
+		t.Fatalf("Expected later pages to only lose the pinned chirp's own entry")
+	}
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/notifications"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// A poll is attached to a chirp when it's sent. The database allows one vote
+// per user per poll, and tallies stay hidden from a viewer until they've
+// voted or the poll has closed, so early results can't sway anyone. Once a
+// poll's closing time passes, the closing job freezes its counts and lets
+// the author know.
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionRunes  = 25
+	maxPollDuration     = 7 * 24 * time.Hour
+	pollClosingInterval = 30 * time.Second
+)
+
+type pollParameters struct {
+	Options  []string  `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+type pollResponse struct {
+	ID            string               `json:"id"`
+	ClosesAt      time.Time            `json:"closes_at"`
+	Closed        bool                 `json:"closed"`
+	Options       []pollOptionResponse `json:"options"`
+	TotalVotes    *int64               `json:"total_votes,omitempty"`
+	VotedOptionID string               `json:"voted_option_id,omitempty"`
+}
+
+// pollOptionResponse leaves Votes out while the tallies are hidden from the
+// viewer.
+type pollOptionResponse struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Votes *int64 `json:"votes,omitempty"`
+}
+
+// checkPoll validates a poll sent with a chirp and returns it with the
+// option labels trimmed and the closing time in UTC, or the message to fail
+// with.
+func checkPoll(p *pollParameters) (*pollParameters, string) {
+	if p == nil {
+		return nil, ""
+	}
+	if len(p.Options) < minPollOptions || len(p.Options) > maxPollOptions {
+		return nil, "A poll needs between 2 and 4 options"
+	}
+
+	options := make([]string, 0, len(p.Options))
+	for _, o := range p.Options {
+		o = strings.TrimSpace(o)
+		if o == "" || utf8.RuneCountInString(o) > maxPollOptionRunes {
+			return nil, "Poll options must be between 1 and 25 characters"
+		}
+		for _, existing := range options {
+			if strings.EqualFold(existing, o) {
+				return nil, "Poll options must be different from each other"
+			}
+		}
+		options = append(options, o)
+	}
+
+	now := time.Now()
+	if !p.ClosesAt.After(now) || p.ClosesAt.After(now.Add(maxPollDuration)) {
+		return nil, "closes_at must be in the future and at most 7 days away"
+	}
+	return &pollParameters{Options: options, ClosesAt: p.ClosesAt.UTC()}, ""
+}
+
+// insertPoll attaches a poll to a chirp inside the caller's transaction.
+func insertPoll(ctx context.Context, qtx *database.Queries, chirpID uuid.UUID, p *pollParameters) error {
+	if p == nil {
+		return nil
+	}
+	poll, err := qtx.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:  chirpID,
+		ClosesAt: p.ClosesAt,
+	})
+	if err != nil {
+		return err
+	}
+	for i, label := range p.Options {
+		err := qtx.CreatePollOption(ctx, database.CreatePollOptionParams{
+			PollID:   poll.ID,
+			Position: int32(i),
+			Label:    label,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildPollResponses looks up the polls attached to a batch of chirps, keyed
+// by chirp ID. Tallies are filled in only where the viewer may see them.
+func (cfg *apiConfig) buildPollResponses(ctx context.Context, chirpIDs []uuid.UUID, viewer uuid.NullUUID) (map[uuid.UUID]*pollResponse, error) {
+	polls := map[uuid.UUID]*pollResponse{}
+	if len(chirpIDs) == 0 {
+		return polls, nil
+	}
+
+	ps, err := cfg.queries.GetPollsByChirpIDs(ctx, chirpIDs)
+	if err != nil || len(ps) == 0 {
+		return polls, err
+	}
+	pollIDs := make([]uuid.UUID, 0, len(ps))
+	for _, p := range ps {
+		pollIDs = append(pollIDs, p.ID)
+	}
+
+	options, err := cfg.queries.GetPollOptions(ctx, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	liveCounts := map[uuid.UUID]int64{}
+	counts, err := cfg.queries.CountPollVotes(ctx, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range counts {
+		liveCounts[c.OptionID] = c.Votes
+	}
+
+	voted := map[uuid.UUID]uuid.UUID{}
+	if viewer.Valid {
+		votes, err := cfg.queries.GetUserPollVotes(ctx, database.GetUserPollVotesParams{
+			UserID:  viewer.UUID,
+			PollIds: pollIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range votes {
+			voted[v.PollID] = v.OptionID
+		}
+	}
+
+	byPoll := map[uuid.UUID][]database.PollOption{}
+	for _, o := range options {
+		byPoll[o.PollID] = append(byPoll[o.PollID], o)
+	}
+
+	now := time.Now()
+	for _, p := range ps {
+		resp := &pollResponse{
+			ID:       p.ID.String(),
+			ClosesAt: p.ClosesAt,
+			Closed:   p.ClosedAt.Valid || !p.ClosesAt.After(now),
+			Options:  []pollOptionResponse{},
+		}
+		votedOption, hasVoted := voted[p.ID]
+		if hasVoted {
+			resp.VotedOptionID = votedOption.String()
+		}
+		showTallies := hasVoted || resp.Closed
+
+		var total int64
+		for _, o := range byPoll[p.ID] {
+			option := pollOptionResponse{ID: o.ID.String(), Label: o.Label}
+			if showTallies {
+				// Finalized polls report the counts frozen at closing.
+				votes := liveCounts[o.ID]
+				if o.FinalVotes.Valid {
+					votes = int64(o.FinalVotes.Int32)
+				}
+				option.Votes = &votes
+				total += votes
+			}
+			resp.Options = append(resp.Options, option)
+		}
+		if showTallies {
+			resp.TotalVotes = &total
+		}
+		polls[p.ChirpID] = resp
+	}
+	return polls, nil
+}
+
+func (cfg *apiConfig) votePoll(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		OptionID uuid.UUID `json:"option_id"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	chirpID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in vote: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong during decoding"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	c, err := cfg.getVisibleChirp(r.Context(), chirpID, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	poll, err := cfg.queries.GetPollByChirp(r.Context(), c.ID)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Chirp has no poll"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	n, err := cfg.queries.VotePoll(r.Context(), database.VotePollParams{
+		UserID:   userID,
+		OptionID: params.OptionID,
+		PollID:   poll.ID,
+		Now:      time.Now().UTC(),
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		w.WriteHeader(http.StatusConflict)
+		resp := map[string]string{"error": "You have already voted in this poll"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Option is not part of this poll"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if err != nil {
+		log.Printf("Error voting in poll: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during vote"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if n == 0 {
+		w.WriteHeader(http.StatusConflict)
+		resp := map[string]string{"error": "Poll is closed"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	polls, err := cfg.buildPollResponses(r.Context(), []uuid.UUID{c.ID}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	jsonResp, err := json.Marshal(polls[c.ID])
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during marshalling"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
+
+// closePolls finalizes every poll past its closing time, one per
+// transaction. As with scheduled chirps, SKIP LOCKED lets several instances
+// share the work, and votes still in flight wait for the poll row and then
+// find it closed, so the frozen counts are the final ones.
+func (cfg *apiConfig) closePolls(ctx context.Context) error {
+	for {
+		p, err := cfg.closeDuePoll(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Nobody needs telling about a poll on a chirp that's in the trash.
+		if p.AuthorID.Valid && !p.ChirpDeletedAt.Valid {
+			cfg.notifier.Notify(notifications.Event{
+				Kind:    notifications.KindPollClosed,
+				UserID:  p.AuthorID.UUID,
+				ChirpID: uuid.NullUUID{UUID: p.ChirpID, Valid: true},
+			})
+		}
+	}
+}
+
+func (cfg *apiConfig) closeDuePoll(ctx context.Context) (database.ClaimDuePollRow, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.ClaimDuePollRow{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	p, err := qtx.ClaimDuePoll(ctx, time.Now().UTC())
+	if err != nil {
+		return database.ClaimDuePollRow{}, err
+	}
+	if err := qtx.FinalizePollOptions(ctx, p.ID); err != nil {
+		return database.ClaimDuePollRow{}, err
+	}
+	if err := qtx.ClosePoll(ctx, p.ID); err != nil {
+		return database.ClaimDuePollRow{}, err
+	}
+	return p, tx.Commit()
+}
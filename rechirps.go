@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// A plain rechirp has no body of its own, so it lives in the rechirps table
+// rather than as a row in chirps. Quotes carry commentary and are ordinary
+// chirps with quote_of set; see send_chirp. Rechirps still show up in the
+// sharer's profile and their followers' timelines, as feed entries pointing
+// at the original chirp.
+
+// feedEntry is one item of a timeline or profile feed: a chirp, either
+// posted by someone in the feed or rechirped by them. Feeds are ordered and
+// paginated by (at, id), which for a chirp's own entry are its created_at and
+// ID, and for a rechirp when it was shared and the rechirp's ID.
+type feedEntry struct {
+	chirp       database.Chirp
+	at          time.Time
+	id          uuid.UUID
+	rechirpedBy uuid.NullUUID
+}
+
+func timelineEntries(rows []database.GetTimelineRow) []feedEntry {
+	entries := make([]feedEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, feedEntry{chirp: row.Chirp, at: row.FeedAt, id: row.EntryID, rechirpedBy: row.RechirpedBy})
+	}
+	return entries
+}
+
+func userFeedEntries(rows []database.GetUserChirpsRow) []feedEntry {
+	entries := make([]feedEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, feedEntry{chirp: row.Chirp, at: row.FeedAt, id: row.EntryID, rechirpedBy: row.RechirpedBy})
+	}
+	return entries
+}
+
+// buildFeedResponses is buildChirpResponses for feed entries, adding who
+// rechirped each chirp that came in through a rechirp.
+func (cfg *apiConfig) buildFeedResponses(ctx context.Context, entries []feedEntry, viewer uuid.NullUUID) ([]chirpResponse, error) {
+	cs := make([]database.Chirp, 0, len(entries))
+	for _, e := range entries {
+		cs = append(cs, e.chirp)
+	}
+	chirps, err := cfg.buildChirpResponses(ctx, cs, viewer)
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range entries {
+		if e.rechirpedBy.Valid {
+			rechirpedAt := e.at
+			chirps[i].RechirpedBy = e.rechirpedBy.UUID.String()
+			chirps[i].RechirpedAt = &rechirpedAt
+		}
+	}
+	return chirps, nil
+}
+
+func (cfg *apiConfig) rechirp(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	chirpID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in rechirp: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	c, err := cfg.getVisibleChirp(r.Context(), chirpID, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	// Rechirping would pass the chirp on to people it wasn't meant for.
+	if c.Visibility != visibilityPublic {
+		w.WriteHeader(http.StatusForbidden)
+		resp := map[string]string{"error": "Only public chirps can be rechirped"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	err = cfg.queries.Rechirp(r.Context(), database.RechirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		log.Printf("Error rechirping chirp: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during rechirp"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) undoRechirp(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	chirpID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in undo rechirp: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	err = cfg.queries.UndoRechirp(r.Context(), database.UndoRechirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		log.Printf("Error undoing rechirp: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during undo rechirp"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+)
+
+// Refresh tokens are single use: every /api/refresh swaps the presented
+// token for a new one in the same family. A rotated token that turns up
+// again has been copied by someone, and since there's no telling whether
+// the thief or the user is holding the newest token, the whole family is
+// revoked and both have to log in again.
+
+// refreshTokenTTL is how long a refresh token lasts if it isn't used. Each
+// rotation starts the clock again.
+const refreshTokenTTL = 14 * 24 * time.Hour
+
+var (
+	errRefreshTokenInvalid = errors.New("refresh token invalid")
+	errRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+// rotateRefreshToken revokes token and issues its replacement to client,
+// returning the old token's row and the new token. The row is locked so two refreshes
+// racing with the same token can't both succeed; the loser is treated as a
+// reuse.
+func (cfg *apiConfig) rotateRefreshToken(ctx context.Context, token string, client clientInfo) (database.RefreshToken, string, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.RefreshToken{}, "", err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	old, err := qtx.LockRefreshToken(ctx, token)
+	if err != nil {
+		return database.RefreshToken{}, "", errRefreshTokenInvalid
+	}
+
+	if old.ReplacedBy.Valid {
+		n, err := qtx.RevokeRefreshTokenFamily(ctx, old.FamilyID)
+		if err != nil {
+			return database.RefreshToken{}, "", err
+		}
+		if err := tx.Commit(); err != nil {
+			return database.RefreshToken{}, "", err
+		}
+		log.Printf("Refresh token reuse detected for user %v: revoked %d tokens in family %v", old.UserID.UUID, n, old.FamilyID)
+		return database.RefreshToken{}, "", errRefreshTokenReused
+	}
+	if old.RevokedAt.Valid || old.ExpiresAt.Before(time.Now()) {
+		return database.RefreshToken{}, "", errRefreshTokenInvalid
+	}
+
+	next, err := auth.MakeRefreshToken()
+	if err != nil {
+		return database.RefreshToken{}, "", err
+	}
+	err = qtx.CreateRotatedRefreshToken(ctx, database.CreateRotatedRefreshTokenParams{
+		Token:     next,
+		UserID:    old.UserID,
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+		FamilyID:  old.FamilyID,
+		UserAgent: client.userAgent,
+		IpAddress: client.ipAddress,
+	})
+	if err != nil {
+		return database.RefreshToken{}, "", err
+	}
+	err = qtx.MarkRefreshTokenRotated(ctx, database.MarkRefreshTokenRotatedParams{
+		Token:      token,
+		ReplacedBy: sql.NullString{String: next, Valid: true},
+	})
+	if err != nil {
+		return database.RefreshToken{}, "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.RefreshToken{}, "", err
+	}
+	return old, next, nil
+}
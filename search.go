@@ -0,0 +1,143 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/mention"
+	"github.com/RobertGolawski/Chirpy/internal/pagination"
+	"github.com/RobertGolawski/Chirpy/internal/search"
+	"github.com/google/uuid"
+)
+
+// searchChirps handles GET /api/chirps/search. Results are ranked by
+// relevance unless sort=asc or sort=desc asks for date order, and are always
+// paginated.
+func (cfg *apiConfig) searchChirps(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q, err := search.ParseQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": err.Error()}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	order := "relevance"
+	o := r.URL.Query().Get("sort")
+	if o == "asc" || o == "desc" {
+		order = o
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Invalid pagination parameters"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	viewer := cfg.viewerID(r)
+	params := database.SearchChirpsParams{
+		ViewerID:  viewer,
+		Query:     q.Text,
+		SortOrder: order,
+		PageLimit: int32(limit + 1),
+	}
+	if q.From != "" {
+		// from: takes either a user ID or an @handle.
+		authorID, err := uuid.Parse(q.From)
+		if err != nil {
+			u, err := cfg.queries.GetUserByHandle(r.Context(), mention.Normalize(q.From))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				resp := map[string]string{"error": "from: must be a user ID or handle"}
+				jsonResp, _ := json.Marshal(resp)
+				w.Write(jsonResp)
+				return
+			}
+			authorID = u.ID
+		}
+		params.AuthorID = uuid.NullUUID{UUID: authorID, Valid: true}
+	}
+	if !q.Since.IsZero() {
+		params.Since = sql.NullTime{Time: q.Since, Valid: true}
+	}
+	if !q.Until.IsZero() {
+		params.Until = sql.NullTime{Time: q.Until, Valid: true}
+	}
+
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		if order == "relevance" {
+			cursor, err := pagination.DecodeRankCursor(c)
+			if err == nil {
+				params.CursorRank = sql.NullFloat64{Float64: float64(cursor.Rank), Valid: true}
+				params.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+			}
+		} else {
+			cursor, err := pagination.DecodeCursor(c)
+			if err == nil {
+				params.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+				params.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+			}
+		}
+		if !params.CursorID.Valid {
+			w.WriteHeader(http.StatusBadRequest)
+			resp := map[string]string{"error": "Invalid pagination parameters"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+	}
+
+	rows, err := cfg.queries.SearchChirps(r.Context(), params)
+	if err != nil {
+		log.Printf("Error searching chirps: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during search"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	nextCursor := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		if order == "relevance" {
+			nextCursor = pagination.EncodeRankCursor(last.Rank, last.Chirp.ID)
+		} else {
+			nextCursor = pagination.EncodeCursor(last.Chirp.CreatedAt, last.Chirp.ID)
+		}
+	}
+
+	cs := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		cs = append(cs, row.Chirp)
+	}
+	chirps, err := cfg.buildChirpResponses(r.Context(), cs, viewer)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	jsonResp, err := json.Marshal(chirpPage{Chirps: chirps, NextCursor: nextCursor})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during response generation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
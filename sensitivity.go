@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+// Sensitive chirps are still listed everywhere, but clients show them
+// collapsed behind their content warning unless the viewer has asked to see
+// them expanded. Authors mark their own chirps; moderators can mark anyone's,
+// and a chirp a moderator marked stays sensitive until a moderator clears it.
+
+const maxContentWarningLength = 100
+
+// parseContentWarning trims a content warning sent by a client. A chirp with
+// a warning is always sensitive, whatever the sensitive flag said.
+func parseContentWarning(warning string, sensitive bool) (sql.NullString, bool, bool) {
+	warning = strings.TrimSpace(warning)
+	if warning == "" {
+		return sql.NullString{}, sensitive, true
+	}
+	if utf8.RuneCountInString(warning) > maxContentWarningLength {
+		return sql.NullString{}, false, false
+	}
+	return sql.NullString{String: warning, Valid: true}, true, true
+}
+
+// viewerShowsSensitive reports whether the viewer wants sensitive chirps
+// shown expanded. Anonymous viewers always see them collapsed.
+func (cfg *apiConfig) viewerShowsSensitive(ctx context.Context, viewer uuid.NullUUID) (bool, error) {
+	if !viewer.Valid {
+		return false, nil
+	}
+	u, err := cfg.queries.GetUser(ctx, viewer.UUID)
+	if err != nil {
+		return false, err
+	}
+	return u.ShowSensitive, nil
+}
+
+func (cfg *apiConfig) setChirpSensitivity(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		ContentWarning string `json:"content_warning"`
+		Sensitive      bool   `json:"sensitive"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	chirpID, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in sensitivity: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		log.Printf("Error decoding JSON: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with parsing JSON"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	warning, sensitive, ok := parseContentWarning(params.ContentWarning, params.Sensitive)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "content_warning can be at most 100 characters"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	u, err := cfg.queries.GetUser(r.Context(), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	// Moderators act on reported chirps, which needn't be visible to them.
+	var c database.Chirp
+	if u.IsModerator {
+		c, err = cfg.queries.GetChirp(r.Context(), chirpID)
+	} else {
+		c, err = cfg.getVisibleChirp(r.Context(), chirpID, uuid.NullUUID{UUID: userID, Valid: true})
+	}
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	byModerator := c.SensitiveByModerator
+	if u.IsModerator {
+		byModerator = sensitive
+	} else {
+		if userID != c.UserID.UUID {
+			w.WriteHeader(http.StatusForbidden)
+			resp := map[string]string{"error": "Forbidden"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+		if byModerator && !sensitive {
+			w.WriteHeader(http.StatusForbidden)
+			resp := map[string]string{"error": "A moderator marked this chirp sensitive"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+	}
+
+	c, err = cfg.queries.UpdateChirpSensitivity(r.Context(), database.UpdateChirpSensitivityParams{
+		ContentWarning:       warning,
+		Sensitive:            sensitive,
+		SensitiveByModerator: byModerator,
+		ID:                   chirpID,
+	})
+	if err != nil {
+		log.Printf("Error updating chirp sensitivity: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during update"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	cfg.publishChirp(r.Context(), stream.EventChirpUpdated, c)
+
+	chirps, err := cfg.buildChirpResponses(r.Context(), []database.Chirp{c}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during update"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	jsonResp, err := json.Marshal(chirps[0])
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during marshalling"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
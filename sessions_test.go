@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCleanUserAgent(t *testing.T) {
+	if got := cleanUserAgent("curl/8.0"); got != "curl/8.0" {
+		t.Fatalf("Expected a short User-Agent to be kept but got %q", got)
+	}
+
+	got := cleanUserAgent("bad\xff\xfeagent")
+	if !utf8.ValidString(got) || !strings.HasPrefix(got, "bad") || !strings.HasSuffix(got, "agent") {
+		t.Fatalf("Expected invalid bytes to be replaced but got %q", got)
+	}
+
+	// A three byte character straddles the limit.
+	long := strings.Repeat("a", maxUserAgentLength-1) + "€€"
+	got = cleanUserAgent(long)
+	if !utf8.ValidString(got) || len(got) > maxUserAgentLength {
+		t.Fatalf("Expected valid UTF-8 of at most %d bytes but got %d bytes", maxUserAgentLength, len(got))
+	}
+	if got != strings.Repeat("a", maxUserAgentLength-1) {
+		t.Fatalf("Expected the split character to be dropped but got %q", got[len(got)-5:])
+	}
+}
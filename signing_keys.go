@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+)
+
+// Access tokens are signed with keys kept in signing_keys, encrypted with
+// SIGNING_KEY_ENCRYPTION_KEY, and published at /.well-known/jwks.json, so
+// other services can verify them without being able to mint them. Every
+// instance loads the keys before it starts serving and reloads them once a
+// minute, and whichever gets there first once the newest key is old enough
+// adds its successor.
+
+const (
+	accessTokenTTL = time.Hour
+	// signingKeyLifetime is how long a key signs tokens before it's replaced.
+	signingKeyLifetime = 30 * 24 * time.Hour
+	// signingKeyPublishDelay is how long a new key is published before it
+	// signs anything. It has to cover every instance reloading the keys and
+	// JWKS caches expiring, which is why jwksMaxAge is well under it.
+	signingKeyPublishDelay    = 10 * time.Minute
+	signingKeyRefreshInterval = time.Minute
+	jwksMaxAge                = 5 * time.Minute
+)
+
+// refreshSigningKeys rotates the signing key if it's due and loads the
+// current keys into cfg.keys. With no keys at all, as on first start, the
+// new key signs straight away so logins work immediately.
+func (cfg *apiConfig) refreshSigningKeys(ctx context.Context) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	// Only one instance at a time decides whether to rotate.
+	if err := qtx.LockSigningKeys(ctx); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if err := qtx.DeleteRetiredSigningKeys(ctx, now); err != nil {
+		return err
+	}
+	rows, err := qtx.GetSigningKeys(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 || now.Sub(rows[len(rows)-1].CreatedAt) >= signingKeyLifetime {
+		notBefore := now.Add(signingKeyPublishDelay)
+		if len(rows) == 0 {
+			notBefore = now
+		}
+		if err := cfg.rotateSigningKey(ctx, qtx, now, notBefore); err != nil {
+			return err
+		}
+		rows, err = qtx.GetSigningKeys(ctx, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	keys := make([]auth.SigningKey, 0, len(rows))
+	for _, row := range rows {
+		der, err := cfg.signingKeyBox.Open(row.EncryptedPrivateKey, []byte(row.Kid))
+		if err != nil {
+			return fmt.Errorf("decrypting signing key %s: %w", row.Kid, err)
+		}
+		private, err := auth.ParsePrivateKey(row.Algorithm, der)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, auth.SigningKey{
+			ID:        row.Kid,
+			Algorithm: row.Algorithm,
+			Private:   private,
+			NotBefore: row.NotBefore,
+			RetiresAt: row.RetiresAt.Time,
+		})
+	}
+	cfg.keys.Replace(keys)
+	return nil
+}
+
+// rotateSigningKey adds a key that signs from notBefore on and retires the
+// others once every token they could have signed has expired. The private
+// key is encrypted with its kid bound in, so it can't be swapped for
+// another row's.
+func (cfg *apiConfig) rotateSigningKey(ctx context.Context, qtx *database.Queries, now, notBefore time.Time) error {
+	k, err := auth.GenerateSigningKey(cfg.signingAlg)
+	if err != nil {
+		return err
+	}
+	der, err := auth.MarshalPrivateKey(k)
+	if err != nil {
+		return err
+	}
+	sealed, err := cfg.signingKeyBox.Seal(der, []byte(k.ID))
+	if err != nil {
+		return err
+	}
+
+	err = qtx.CreateSigningKey(ctx, database.CreateSigningKeyParams{
+		Kid:                 k.ID,
+		Algorithm:           k.Algorithm,
+		EncryptedPrivateKey: sealed,
+		CreatedAt:           now,
+		NotBefore:           notBefore,
+	})
+	if err != nil {
+		return err
+	}
+	return qtx.RetireSigningKeys(ctx, database.RetireSigningKeysParams{
+		RetiresAt: sql.NullTime{Time: notBefore.Add(accessTokenTTL), Valid: true},
+		Kid:       k.ID,
+	})
+}
+
+func (cfg *apiConfig) getJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	jsonResp, err := json.Marshal(cfg.keys.JWKS(time.Now()))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during marshalling"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(jwksMaxAge.Seconds())))
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
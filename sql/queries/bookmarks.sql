@@ -0,0 +1,35 @@
+-- name: BookmarkChirp :exec
+INSERT INTO chirp_bookmarks (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnbookmarkChirp :exec
+DELETE
+FROM chirp_bookmarks
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id
+FROM chirp_bookmarks
+WHERE user_id = sqlc.arg('user_id') AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- Newest bookmark first. The cursor is the bookmark's timestamp rather than
+-- the chirp's, since that's what the list is ordered by.
+-- name: GetUserBookmarks :many
+SELECT sqlc.embed(chirps), chirp_bookmarks.created_at AS bookmarked_at
+FROM chirp_bookmarks
+JOIN chirps ON chirps.id = chirp_bookmarks.chirp_id
+WHERE chirp_bookmarks.user_id = sqlc.arg('user_id')
+    AND chirps.deleted_at IS NULL
+    AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg('user_id'))
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (chirp_bookmarks.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY chirp_bookmarks.created_at DESC, chirps.id DESC
+LIMIT sqlc.narg('page_limit');
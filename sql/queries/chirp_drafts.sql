@@ -0,0 +1,57 @@
+-- name: CreateDraft :one
+INSERT INTO chirp_drafts (id, created_at, updated_at, user_id, body, in_reply_to, quote_of, publish_at, media_ids, visibility, content_warning, sensitive)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
+
+-- name: GetDraft :one
+SELECT *
+FROM chirp_drafts
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetUserDrafts :many
+SELECT *
+FROM chirp_drafts
+WHERE user_id = $1
+ORDER BY updated_at DESC;
+
+-- name: UpdateDraft :one
+UPDATE chirp_drafts
+SET body = $3, in_reply_to = $4, quote_of = $5, publish_at = $6, media_ids = $7, visibility = $8, content_warning = $9, sensitive = $10, last_error = NULL, updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM chirp_drafts
+WHERE id = $1 AND user_id = $2;
+
+-- name: LockDraft :one
+SELECT *
+FROM chirp_drafts
+WHERE id = $1 AND user_id = $2
+FOR UPDATE;
+
+-- name: ClaimDueDraft :one
+SELECT *
+FROM chirp_drafts
+WHERE publish_at <= sqlc.arg('now')::timestamp
+ORDER BY publish_at ASC
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: UnscheduleDraft :exec
+UPDATE chirp_drafts
+SET publish_at = NULL, last_error = $2, updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,29 @@
+-- name: GetChirpForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+FOR UPDATE;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CountChirpRevisions :one
+SELECT COUNT(*) FROM chirp_revisions
+WHERE chirp_id = $1;
+
+-- name: CreateChirpRevision :exec
+INSERT INTO chirp_revisions (id, chirp_id, revision, body, created_at)
+VALUES (
+    gen_random_uuid(),
+    sqlc.arg('chirp_id'),
+    COALESCE((SELECT MAX(revision) FROM chirp_revisions WHERE chirp_id = sqlc.arg('chirp_id')), 0) + 1,
+    sqlc.arg('body'),
+    sqlc.arg('created_at')
+);
+
+-- name: GetChirpRevisions :many
+SELECT * FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY revision ASC;
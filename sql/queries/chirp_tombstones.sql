@@ -0,0 +1,18 @@
+-- name: CreateChirpTombstone :exec
+INSERT INTO chirp_tombstones (id, created_at, deleted_at, in_reply_to)
+VALUES (
+    $1,
+    $2,
+    NOW(),
+    $3
+)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: GetChirpTombstone :one
+SELECT *
+FROM chirp_tombstones
+WHERE id = $1;
+
+-- name: DeleteChirpTombstone :exec
+DELETE FROM chirp_tombstones
+WHERE id = $1;
@@ -0,0 +1,31 @@
+-- name: GetTrashedChirp :one
+SELECT *
+FROM chirps
+WHERE id = $1
+    AND deleted_at IS NOT NULL
+    AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP);
+
+-- name: GetUserTrash :many
+SELECT *
+FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+    AND deleted_at > LOCALTIMESTAMP - make_interval(secs => sqlc.arg('retention_seconds')::float8)
+    AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+ORDER BY deleted_at DESC;
+
+-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = sqlc.arg('id')
+    AND deleted_at > LOCALTIMESTAMP - make_interval(secs => sqlc.arg('retention_seconds')::float8)
+    AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+RETURNING *;
+
+-- name: PurgeExpiredChirps :execrows
+DELETE FROM chirps
+WHERE id IN (
+    SELECT id
+    FROM chirps
+    WHERE deleted_at <= LOCALTIMESTAMP - make_interval(secs => sqlc.arg('retention_seconds')::float8)
+    LIMIT sqlc.arg('batch_size')
+);
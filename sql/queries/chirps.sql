@@ -1,10 +1,16 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, quote_of, visibility, expires_at, content_warning, sensitive)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
-    $1,
-    $2
+    sqlc.arg('body'),
+    sqlc.arg('user_id'),
+    sqlc.arg('in_reply_to'),
+    sqlc.arg('quote_of'),
+    sqlc.arg('visibility'),
+    NOW() + make_interval(secs => sqlc.narg('expires_in_seconds')::float8),
+    sqlc.narg('content_warning'),
+    sqlc.arg('sensitive')
 )
-RETURNING *;
\ No newline at end of file
+RETURNING *;
@@ -1,4 +1,4 @@
 -- name: DeleteChirp :exec
-DELETE 
-FROM chirps
-WHERE id = $1;
+UPDATE chirps
+SET deleted_at = NOW(), pinned_at = NULL
+WHERE id = $1 AND deleted_at IS NULL;
@@ -0,0 +1,13 @@
+-- Ephemeral chirps are claimed one at a time, whether or not their author
+-- already put them in the trash, since an expired chirp can't be restored.
+-- name: ClaimExpiredChirp :one
+SELECT *
+FROM chirps
+WHERE expires_at <= LOCALTIMESTAMP
+ORDER BY expires_at ASC
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeleteChirpPermanently :exec
+DELETE FROM chirps
+WHERE id = $1;
@@ -1,4 +1,12 @@
 -- name: GetChirp :one
 SELECT *
 FROM chirps
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP);
+
+-- name: GetVisibleChirp :one
+SELECT *
+FROM chirps
+WHERE id = sqlc.arg('id')
+    AND deleted_at IS NULL
+    AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id')::uuid);
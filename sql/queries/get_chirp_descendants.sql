@@ -0,0 +1,24 @@
+-- name: GetChirpDescendants :many
+-- Replies the viewer can't see are left out along with everything under
+-- them, so a hidden chirp doesn't show up as a gap in the thread.
+WITH RECURSIVE thread AS (
+    SELECT nodes.id, nodes.in_reply_to, nodes.created_at, 1 AS depth
+    FROM (
+        SELECT chirps.id, chirps.in_reply_to, chirps.created_at FROM chirps WHERE chirps.deleted_at IS NULL AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP) AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+        UNION ALL
+        SELECT chirp_tombstones.id, chirp_tombstones.in_reply_to, chirp_tombstones.created_at FROM chirp_tombstones
+    ) AS nodes
+    WHERE nodes.in_reply_to = sqlc.arg('chirp_id')::uuid
+    UNION ALL
+    SELECT nodes.id, nodes.in_reply_to, nodes.created_at, thread.depth + 1
+    FROM (
+        SELECT chirps.id, chirps.in_reply_to, chirps.created_at FROM chirps WHERE chirps.deleted_at IS NULL AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP) AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+        UNION ALL
+        SELECT chirp_tombstones.id, chirp_tombstones.in_reply_to, chirp_tombstones.created_at FROM chirp_tombstones
+    ) AS nodes
+    JOIN thread ON nodes.in_reply_to = thread.id
+    WHERE thread.depth < sqlc.arg('max_depth')::int
+)
+SELECT thread.id, thread.in_reply_to, thread.depth
+FROM thread
+ORDER BY thread.depth ASC, thread.created_at ASC;
@@ -1,6 +1,18 @@
 -- name: GetChirps :many
 SELECT *
 FROM chirps
+WHERE deleted_at IS NULL
+    AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.arg('include_sensitive')::boolean OR NOT sensitive)
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (sqlc.arg('sort_order') = 'desc' AND (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+        OR (sqlc.arg('sort_order') = 'asc' AND (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+    )
 ORDER BY 
     CASE WHEN sqlc.arg('sort_order') = 'desc' THEN created_at END DESC,
-    CASE WHEN sqlc.arg('sort_order') = 'asc' THEN created_at END ASC;
+    CASE WHEN sqlc.arg('sort_order') = 'desc' THEN id END DESC,
+    CASE WHEN sqlc.arg('sort_order') = 'asc' THEN created_at END ASC,
+    CASE WHEN sqlc.arg('sort_order') = 'asc' THEN id END ASC
+LIMIT sqlc.narg('page_limit');
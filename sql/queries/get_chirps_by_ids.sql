@@ -0,0 +1,7 @@
+-- name: GetChirpsByIDs :many
+SELECT *
+FROM chirps
+WHERE id = ANY(sqlc.arg('chirp_ids')::uuid[])
+    AND deleted_at IS NULL
+    AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id')::uuid);
@@ -0,0 +1,11 @@
+-- name: GetFollowers :many
+SELECT users.id, users.handle, users.is_chirpy_red, follows.created_at AS followed_at
+FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = $1
+ORDER BY follows.created_at DESC;
+
+-- name: GetFollowerIDs :many
+SELECT follower_id
+FROM follows
+WHERE followee_id = $1;
@@ -0,0 +1,6 @@
+-- name: GetFollowing :many
+SELECT users.id, users.handle, users.is_chirpy_red, follows.created_at AS followed_at
+FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = $1
+ORDER BY follows.created_at DESC;
@@ -0,0 +1,31 @@
+-- name: GetTimeline :many
+-- The followed users' chirps and rechirps, merged into one feed ordered by
+-- when each entry was posted or shared. A rechirp entry has its own ID and
+-- rechirped_by set.
+SELECT sqlc.embed(chirps), feed.feed_at, feed.entry_id, feed.rechirped_by
+FROM (
+    SELECT chirps.id AS chirp_id, chirps.created_at AS feed_at, chirps.id AS entry_id, NULL::uuid AS rechirped_by
+    FROM chirps
+    JOIN follows ON follows.followee_id = chirps.user_id
+    WHERE follows.follower_id = sqlc.arg('follower_id')
+    UNION ALL
+    SELECT rechirps.chirp_id, rechirps.created_at, rechirps.id, rechirps.user_id
+    FROM rechirps
+    JOIN follows ON follows.followee_id = rechirps.user_id
+    WHERE follows.follower_id = sqlc.arg('follower_id')
+) AS feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+    AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg('follower_id'))
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (sqlc.arg('sort_order') = 'desc' AND (feed.feed_at, feed.entry_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+        OR (sqlc.arg('sort_order') = 'asc' AND (feed.feed_at, feed.entry_id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+    )
+ORDER BY 
+    CASE WHEN sqlc.arg('sort_order') = 'desc' THEN feed.feed_at END DESC,
+    CASE WHEN sqlc.arg('sort_order') = 'desc' THEN feed.entry_id END DESC,
+    CASE WHEN sqlc.arg('sort_order') = 'asc' THEN feed.feed_at END ASC,
+    CASE WHEN sqlc.arg('sort_order') = 'asc' THEN feed.entry_id END ASC
+LIMIT sqlc.narg('page_limit');
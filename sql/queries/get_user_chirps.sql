@@ -1,7 +1,29 @@
 -- name: GetUserChirps :many
-SELECT *
-FROM chirps
-WHERE $1 = user_id
+-- The user's own chirps and their rechirps, merged the same way as
+-- GetTimeline.
+SELECT sqlc.embed(chirps), feed.feed_at, feed.entry_id, feed.rechirped_by
+FROM (
+    SELECT chirps.id AS chirp_id, chirps.created_at AS feed_at, chirps.id AS entry_id, NULL::uuid AS rechirped_by
+    FROM chirps
+    WHERE chirps.user_id = sqlc.arg('user_id')
+    UNION ALL
+    SELECT rechirps.chirp_id, rechirps.created_at, rechirps.id, rechirps.user_id
+    FROM rechirps
+    WHERE rechirps.user_id = sqlc.arg('user_id')
+) AS feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+    AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.arg('include_sensitive')::boolean OR NOT chirps.sensitive)
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (sqlc.arg('sort_order') = 'desc' AND (feed.feed_at, feed.entry_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+        OR (sqlc.arg('sort_order') = 'asc' AND (feed.feed_at, feed.entry_id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+    )
 ORDER BY 
-    CASE WHEN sqlc.arg('sort_order') = 'desc' THEN created_at END DESC,
-    CASE WHEN sqlc.arg('sort_order') = 'asc' THEN created_at END ASC;
+    CASE WHEN sqlc.arg('sort_order') = 'desc' THEN feed.feed_at END DESC,
+    CASE WHEN sqlc.arg('sort_order') = 'desc' THEN feed.entry_id END DESC,
+    CASE WHEN sqlc.arg('sort_order') = 'asc' THEN feed.feed_at END ASC,
+    CASE WHEN sqlc.arg('sort_order') = 'asc' THEN feed.entry_id END ASC
+LIMIT sqlc.narg('page_limit');
@@ -0,0 +1,14 @@
+-- name: UpdateHandle :exec
+UPDATE users
+SET handle = $1, updated_at = NOW()
+WHERE id = $2;
+
+-- name: GetUserByHandle :one
+SELECT *
+FROM users
+WHERE LOWER(handle) = LOWER(sqlc.arg('handle'));
+
+-- name: GetUsersByHandles :many
+SELECT id, handle
+FROM users
+WHERE LOWER(handle) = ANY(sqlc.arg('handles')::text[]);
@@ -0,0 +1,66 @@
+-- name: UpsertHashtag :one
+INSERT INTO hashtags (id, created_at, tag)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1
+)
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING id;
+
+-- name: AddChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (chirp_id, hashtag_id) DO NOTHING;
+
+-- name: GetChirpHashtags :many
+SELECT chirp_hashtags.chirp_id, hashtags.tag
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE chirp_hashtags.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY hashtags.tag;
+
+-- name: GetHashtagChirps :many
+SELECT chirps.*
+FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.tag = sqlc.arg('tag')
+    AND chirps.deleted_at IS NULL
+    AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (sqlc.arg('sort_order') = 'desc' AND (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+        OR (sqlc.arg('sort_order') = 'asc' AND (chirps.created_at, chirps.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+    )
+ORDER BY 
+    CASE WHEN sqlc.arg('sort_order') = 'desc' THEN chirps.created_at END DESC,
+    CASE WHEN sqlc.arg('sort_order') = 'desc' THEN chirps.id END DESC,
+    CASE WHEN sqlc.arg('sort_order') = 'asc' THEN chirps.created_at END ASC,
+    CASE WHEN sqlc.arg('sort_order') = 'asc' THEN chirps.id END ASC
+LIMIT sqlc.narg('page_limit');
+
+-- name: GetTrendingHashtags :many
+SELECT hashtags.tag,
+    COUNT(*) AS uses,
+    SUM(EXP(-LN(2) * EXTRACT(EPOCH FROM (LOCALTIMESTAMP - chirp_hashtags.created_at)) / sqlc.arg('half_life_seconds')::float8))::float8 AS score
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirps.deleted_at IS NULL
+    AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+    AND chirps.visibility = 'public'
+    AND chirp_hashtags.created_at > LOCALTIMESTAMP - make_interval(secs => sqlc.arg('window_seconds')::float8)
+GROUP BY hashtags.tag
+ORDER BY score DESC, hashtags.tag ASC
+LIMIT sqlc.arg('max_tags');
+
+-- name: DeleteStaleChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = sqlc.arg('chirp_id')
+    AND NOT (hashtag_id = ANY(sqlc.arg('keep_ids')::uuid[]));
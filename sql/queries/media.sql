@@ -0,0 +1,63 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, content_type, blob_key, size_bytes, width, height, thumbnail_key, thumbnail_content_type)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING *;
+
+-- name: GetMedia :one
+SELECT *
+FROM media
+WHERE id = $1;
+
+-- name: CountUserMedia :one
+SELECT COUNT(*)
+FROM media
+WHERE user_id = sqlc.arg('user_id') AND id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: AddChirpMedia :exec
+INSERT INTO chirp_media (chirp_id, media_id, position)
+VALUES (
+    $1,
+    $2,
+    $3
+);
+
+-- name: GetChirpMedia :many
+SELECT chirp_media.chirp_id, sqlc.embed(media)
+FROM chirp_media
+JOIN media ON media.id = chirp_media.media_id
+WHERE chirp_media.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_media.chirp_id, chirp_media.position;
+
+-- name: GetMediaAccess :one
+-- Whether the viewer can see a chirp the media is attached to, and whether
+-- one of those chirps is public with no expiry, so anyone can cache it.
+SELECT
+    EXISTS (
+        SELECT 1
+        FROM chirp_media
+        JOIN chirps ON chirps.id = chirp_media.chirp_id
+        WHERE chirp_media.media_id = sqlc.arg('media_id')
+            AND chirps.deleted_at IS NULL
+            AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+            AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+    ) AS visible,
+    EXISTS (
+        SELECT 1
+        FROM chirp_media
+        JOIN chirps ON chirps.id = chirp_media.chirp_id
+        WHERE chirp_media.media_id = sqlc.arg('media_id')
+            AND chirps.deleted_at IS NULL
+            AND chirps.expires_at IS NULL
+            AND chirps.visibility = 'public'
+    ) AS public;
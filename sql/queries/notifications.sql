@@ -0,0 +1,59 @@
+-- name: UpsertNotification :one
+INSERT INTO notifications (id, created_at, updated_at, user_id, kind, chirp_id, group_key, actor_count, latest_actor_id, read_at)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    0,
+    $5,
+    NULL
+)
+ON CONFLICT (user_id, group_key) WHERE read_at IS NULL
+DO UPDATE SET updated_at = NOW(), latest_actor_id = COALESCE(EXCLUDED.latest_actor_id, notifications.latest_actor_id)
+RETURNING id;
+
+-- name: AddNotificationActor :exec
+INSERT INTO notification_actors (notification_id, actor_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (notification_id, actor_id) DO NOTHING;
+
+-- name: RefreshNotificationActorCount :exec
+UPDATE notifications
+SET actor_count = (
+    SELECT COUNT(*)
+    FROM notification_actors
+    WHERE notification_actors.notification_id = notifications.id
+)
+WHERE id = $1;
+
+-- name: GetNotifications :many
+SELECT *
+FROM notifications
+WHERE user_id = sqlc.arg('user_id')
+    AND (NOT sqlc.arg('unread_only')::boolean OR read_at IS NULL)
+    AND (
+        sqlc.narg('cursor_updated_at')::timestamp IS NULL
+        OR (updated_at, id) < (sqlc.narg('cursor_updated_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY updated_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: MarkNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+    AND read_at IS NULL
+    AND id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
@@ -0,0 +1,29 @@
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (token_hash, user_id, created_at, expires_at)
+VALUES (
+    sqlc.arg('token_hash'),
+    sqlc.arg('user_id'),
+    NOW(),
+    NOW() + make_interval(secs => sqlc.arg('ttl_seconds')::float8)
+);
+
+-- name: CountRecentPasswordResetTokens :one
+SELECT COUNT(*)
+FROM password_reset_tokens
+WHERE user_id = sqlc.arg('user_id')
+    AND created_at > LOCALTIMESTAMP - make_interval(secs => sqlc.arg('window_seconds')::float8);
+
+-- name: UsePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > LOCALTIMESTAMP
+RETURNING user_id;
+
+-- name: InvalidatePasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: DeleteExpiredPasswordResetTokens :exec
+DELETE FROM password_reset_tokens
+WHERE expires_at <= LOCALTIMESTAMP;
@@ -0,0 +1,33 @@
+-- Taken before counting a user's pins, so two requests can't both see room
+-- for one more.
+-- name: LockUserPins :exec
+SELECT id
+FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CountPinnedChirps :one
+SELECT COUNT(*)
+FROM chirps
+WHERE user_id = $1 AND pinned_at IS NOT NULL AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP);
+
+-- name: PinChirp :execrows
+UPDATE chirps
+SET pinned_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND pinned_at IS NULL;
+
+-- name: UnpinChirp :exec
+UPDATE chirps
+SET pinned_at = NULL
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetPinnedChirps :many
+SELECT *
+FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+    AND pinned_at IS NOT NULL
+    AND deleted_at IS NULL
+    AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.arg('include_sensitive')::boolean OR NOT sensitive)
+ORDER BY pinned_at DESC;
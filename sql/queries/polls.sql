@@ -0,0 +1,80 @@
+-- name: CreatePoll :one
+INSERT INTO polls (id, chirp_id, created_at, closes_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    NOW(),
+    $2
+)
+RETURNING *;
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options (id, poll_id, position, label)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3
+);
+
+-- name: GetPollByChirp :one
+SELECT *
+FROM polls
+WHERE chirp_id = $1;
+
+-- name: GetPollsByChirpIDs :many
+SELECT *
+FROM polls
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: GetPollOptions :many
+SELECT *
+FROM poll_options
+WHERE poll_id = ANY(sqlc.arg('poll_ids')::uuid[])
+ORDER BY poll_id, position;
+
+-- name: CountPollVotes :many
+SELECT option_id, COUNT(*) AS votes
+FROM poll_votes
+WHERE poll_id = ANY(sqlc.arg('poll_ids')::uuid[])
+GROUP BY option_id;
+
+-- name: GetUserPollVotes :many
+SELECT poll_id, option_id
+FROM poll_votes
+WHERE user_id = sqlc.arg('user_id') AND poll_id = ANY(sqlc.arg('poll_ids')::uuid[]);
+
+-- Inserts nothing once the poll has closed. FOR SHARE makes a vote wait for
+-- a closing job that's finalizing the same poll, then see it as closed.
+-- name: VotePoll :execrows
+INSERT INTO poll_votes (poll_id, user_id, option_id, created_at)
+SELECT polls.id, sqlc.arg('user_id'), sqlc.arg('option_id'), NOW()
+FROM polls
+WHERE polls.id = sqlc.arg('poll_id')
+    AND polls.closed_at IS NULL
+    AND polls.closes_at > sqlc.arg('now')::timestamp
+FOR SHARE;
+
+-- name: ClaimDuePoll :one
+SELECT polls.id, polls.chirp_id, chirps.user_id AS author_id, chirps.deleted_at AS chirp_deleted_at
+FROM polls
+JOIN chirps ON chirps.id = polls.chirp_id
+WHERE polls.closed_at IS NULL
+    AND polls.closes_at <= sqlc.arg('now')::timestamp
+ORDER BY polls.closes_at ASC
+LIMIT 1
+FOR UPDATE OF polls SKIP LOCKED;
+
+-- name: FinalizePollOptions :exec
+UPDATE poll_options
+SET final_votes = (
+    SELECT COUNT(*)
+    FROM poll_votes
+    WHERE poll_votes.option_id = poll_options.id
+)
+WHERE poll_options.poll_id = $1;
+
+-- name: ClosePoll :exec
+UPDATE polls
+SET closed_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,19 @@
+-- name: Rechirp :exec
+INSERT INTO rechirps (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UndoRechirp :exec
+DELETE
+FROM rechirps
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: CountRechirps :many
+SELECT chirp_id, COUNT(*) AS rechirp_count
+FROM rechirps
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY chirp_id;
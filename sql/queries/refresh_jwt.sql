@@ -1,4 +1,30 @@
--- name: RefreshJWT :exec
+-- name: LockRefreshToken :one
+SELECT *
+FROM refresh_tokens
+WHERE token = $1
+FOR UPDATE;
+
+-- name: MarkRefreshTokenRotated :exec
 UPDATE refresh_tokens
-SET token = $1
-WHERE user_id = $2;
+SET replaced_by = $2, revoked_at = NOW(), updated_at = NOW()
+WHERE token = $1;
+
+-- name: CreateRotatedRefreshToken :exec
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, user_agent, ip_address, last_used_at)
+VALUES (
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    $3,
+    NULL,
+    $4,
+    $5,
+    $6,
+    NOW()
+);
+
+-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL;
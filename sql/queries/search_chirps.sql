@@ -0,0 +1,24 @@
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps), ts_rank(chirps.search_vector, websearch_to_tsquery('english', sqlc.arg('query')))::real AS rank
+FROM chirps
+WHERE chirps.search_vector @@ websearch_to_tsquery('english', sqlc.arg('query'))
+    AND chirps.deleted_at IS NULL
+    AND (chirps.expires_at IS NULL OR chirps.expires_at > LOCALTIMESTAMP)
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id')::uuid)
+    AND (sqlc.narg('since')::timestamp IS NULL OR chirps.created_at >= sqlc.narg('since')::timestamp)
+    AND (sqlc.narg('until')::timestamp IS NULL OR chirps.created_at < sqlc.narg('until')::timestamp)
+    AND (
+        sqlc.narg('cursor_id')::uuid IS NULL
+        OR (sqlc.arg('sort_order') = 'relevance' AND (ts_rank(chirps.search_vector, websearch_to_tsquery('english', sqlc.arg('query')))::real, chirps.id) < (sqlc.narg('cursor_rank')::real, sqlc.narg('cursor_id')::uuid))
+        OR (sqlc.arg('sort_order') = 'desc' AND (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+        OR (sqlc.arg('sort_order') = 'asc' AND (chirps.created_at, chirps.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+    )
+ORDER BY
+    CASE WHEN sqlc.arg('sort_order') = 'relevance' THEN ts_rank(chirps.search_vector, websearch_to_tsquery('english', sqlc.arg('query')))::real END DESC,
+    CASE WHEN sqlc.arg('sort_order') = 'relevance' THEN chirps.id END DESC,
+    CASE WHEN sqlc.arg('sort_order') = 'desc' THEN chirps.created_at END DESC,
+    CASE WHEN sqlc.arg('sort_order') = 'desc' THEN chirps.id END DESC,
+    CASE WHEN sqlc.arg('sort_order') = 'asc' THEN chirps.created_at END ASC,
+    CASE WHEN sqlc.arg('sort_order') = 'asc' THEN chirps.id END ASC
+LIMIT sqlc.arg('page_limit');
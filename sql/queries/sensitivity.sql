@@ -0,0 +1,12 @@
+-- name: UpdateChirpSensitivity :one
+UPDATE chirps
+SET content_warning = sqlc.narg('content_warning'),
+    sensitive = sqlc.arg('sensitive'),
+    sensitive_by_moderator = sqlc.arg('sensitive_by_moderator')
+WHERE id = sqlc.arg('id') AND deleted_at IS NULL
+RETURNING *;
+
+-- name: UpdateShowSensitive :exec
+UPDATE users
+SET show_sensitive = $1, updated_at = NOW()
+WHERE id = $2;
@@ -0,0 +1,27 @@
+-- name: GetUserSessions :many
+SELECT
+    t.family_id,
+    t.user_agent,
+    t.ip_address,
+    COALESCE(t.last_used_at, t.created_at)::timestamp AS last_used_at,
+    t.expires_at,
+    (
+        SELECT MIN(f.created_at)
+        FROM refresh_tokens f
+        WHERE f.family_id = t.family_id
+    )::timestamp AS started_at
+FROM refresh_tokens t
+WHERE t.user_id = sqlc.arg('user_id')
+    AND t.revoked_at IS NULL
+    AND t.expires_at > sqlc.arg('now')::timestamp
+ORDER BY COALESCE(t.last_used_at, t.created_at) DESC;
+
+-- name: RevokeSession :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
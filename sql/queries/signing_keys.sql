@@ -0,0 +1,21 @@
+-- name: LockSigningKeys :exec
+SELECT pg_advisory_xact_lock(hashtext('signing_keys'));
+
+-- name: GetSigningKeys :many
+SELECT *
+FROM signing_keys
+WHERE retires_at IS NULL OR retires_at > sqlc.arg('now')::timestamp
+ORDER BY not_before ASC;
+
+-- name: CreateSigningKey :exec
+INSERT INTO signing_keys (kid, algorithm, encrypted_private_key, created_at, not_before)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: RetireSigningKeys :exec
+UPDATE signing_keys
+SET retires_at = sqlc.arg('retires_at')
+WHERE retires_at IS NULL AND kid <> sqlc.arg('kid');
+
+-- name: DeleteRetiredSigningKeys :exec
+DELETE FROM signing_keys
+WHERE retires_at <= sqlc.arg('now')::timestamp;
@@ -0,0 +1,78 @@
+-- name: StartTOTPSetup :execrows
+UPDATE users
+SET totp_secret = $2, totp_last_step = NULL, updated_at = NOW()
+WHERE id = $1 AND totp_enabled_at IS NULL;
+
+-- name: EnableTOTP :execrows
+UPDATE users
+SET totp_enabled_at = NOW(), totp_last_step = sqlc.arg('step')::bigint, updated_at = NOW()
+WHERE id = sqlc.arg('id') AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL;
+
+-- name: DisableTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL,
+    mfa_attempts = 0, mfa_locked_until = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = sqlc.arg('step')::bigint
+WHERE id = sqlc.arg('id')
+    AND (totp_last_step IS NULL OR totp_last_step < sqlc.arg('step')::bigint);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM mfa_recovery_codes
+WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at)
+VALUES ($1, $2, NOW());
+
+-- name: UseRecoveryCode :execrows
+UPDATE mfa_recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CreateMFAChallenge :exec
+INSERT INTO mfa_challenges (token_hash, user_id, created_at, expires_at)
+VALUES (
+    sqlc.arg('token_hash'),
+    sqlc.arg('user_id'),
+    NOW(),
+    NOW() + make_interval(secs => sqlc.arg('ttl_seconds')::float8)
+);
+
+-- name: ClaimMFAChallengeAttempt :one
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE token_hash = sqlc.arg('token_hash')
+    AND expires_at > LOCALTIMESTAMP
+    AND attempts < sqlc.arg('max_attempts')::int
+RETURNING user_id;
+
+-- name: ClaimUserMFAAttempt :execrows
+UPDATE users
+SET mfa_attempts = CASE
+        WHEN mfa_attempts + 1 >= sqlc.arg('max_attempts')::int THEN 0
+        ELSE mfa_attempts + 1
+    END,
+    mfa_locked_until = CASE
+        WHEN mfa_attempts + 1 >= sqlc.arg('max_attempts')::int
+            THEN LOCALTIMESTAMP + make_interval(secs => sqlc.arg('lockout_seconds')::float8)
+        ELSE NULL
+    END
+WHERE id = sqlc.arg('id')
+    AND (mfa_locked_until IS NULL OR mfa_locked_until <= LOCALTIMESTAMP);
+
+-- name: ResetUserMFAAttempts :exec
+UPDATE users
+SET mfa_attempts = 0, mfa_locked_until = NULL
+WHERE id = $1;
+
+-- name: DeleteMFAChallenge :execrows
+DELETE FROM mfa_challenges
+WHERE token_hash = $1;
+
+-- name: DeleteExpiredMFAChallenges :exec
+DELETE FROM mfa_challenges
+WHERE expires_at <= LOCALTIMESTAMP;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN in_reply_to UUID;
+
+CREATE INDEX chirps_in_reply_to_idx ON chirps(in_reply_to);
+
+CREATE TABLE chirp_tombstones(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP NOT NULL,
+    in_reply_to UUID
+);
+
+CREATE INDEX chirp_tombstones_in_reply_to_idx ON chirp_tombstones(in_reply_to);
+
+-- +goose Down
+DROP TABLE chirp_tombstones;
+
+ALTER TABLE chirps
+DROP COLUMN in_reply_to;
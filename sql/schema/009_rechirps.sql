@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN quote_of UUID;
+
+CREATE INDEX chirps_quote_of_idx ON chirps(quote_of);
+
+CREATE TABLE rechirps(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX rechirps_chirp_id_idx ON rechirps(chirp_id);
+
+-- +goose Down
+DROP TABLE rechirps;
+
+ALTER TABLE chirps
+DROP COLUMN quote_of;
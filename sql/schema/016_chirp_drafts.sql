@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE chirp_drafts(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    in_reply_to UUID,
+    quote_of UUID,
+    publish_at TIMESTAMP,
+    last_error TEXT
+);
+
+CREATE INDEX chirp_drafts_user_id_idx ON chirp_drafts(user_id, updated_at);
+CREATE INDEX chirp_drafts_publish_at_idx ON chirp_drafts(publish_at) WHERE publish_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE chirp_drafts;
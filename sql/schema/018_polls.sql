@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE polls(
+    id UUID PRIMARY KEY,
+    chirp_id UUID UNIQUE NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    closes_at TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP
+);
+
+CREATE INDEX polls_closes_at_idx ON polls(closes_at) WHERE closed_at IS NULL;
+
+CREATE TABLE poll_options(
+    id UUID PRIMARY KEY,
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    label TEXT NOT NULL,
+    final_votes INTEGER,
+    UNIQUE (poll_id, position),
+    UNIQUE (poll_id, id)
+);
+
+-- The primary key allows one vote per user per poll, and the composite
+-- foreign key makes sure the option belongs to the poll being voted in.
+CREATE TABLE poll_votes(
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    option_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (poll_id, user_id),
+    FOREIGN KEY (poll_id, option_id) REFERENCES poll_options(poll_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX poll_votes_option_id_idx ON poll_votes(option_id);
+
+-- +goose Down
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
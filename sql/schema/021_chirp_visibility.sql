@@ -0,0 +1,46 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'followers', 'mentioned'));
+
+ALTER TABLE chirp_drafts
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'followers', 'mentioned'));
+
+-- chirp_visible_to is the one place the visibility rules live, so every read
+-- query applies them the same way. The author and anyone mentioned can always
+-- see a chirp; followers-only chirps are also visible to the author's
+-- followers. A NULL viewer is an anonymous request and only sees public
+-- chirps.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_visible_to(chirp_id UUID, author_id UUID, visibility TEXT, viewer_id UUID)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+    SELECT visibility = 'public'
+        OR (viewer_id IS NOT NULL AND (
+            author_id = viewer_id
+            OR EXISTS (
+                SELECT 1
+                FROM chirp_mentions
+                WHERE chirp_mentions.chirp_id = chirp_visible_to.chirp_id
+                    AND chirp_mentions.user_id = viewer_id
+            )
+            OR (visibility = 'followers' AND EXISTS (
+                SELECT 1
+                FROM follows
+                WHERE follows.follower_id = viewer_id
+                    AND follows.followee_id = author_id
+            ))
+        ));
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_visible_to;
+
+ALTER TABLE chirp_drafts
+DROP COLUMN visibility;
+
+ALTER TABLE chirps
+DROP COLUMN visibility;
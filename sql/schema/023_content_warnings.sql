@@ -0,0 +1,31 @@
+-- +goose Up
+-- A chirp with a content_warning is always sensitive. sensitive_by_moderator
+-- records that a moderator marked it, so its author can't clear the flag.
+ALTER TABLE chirps
+ADD COLUMN content_warning TEXT,
+ADD COLUMN sensitive BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN sensitive_by_moderator BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE chirp_drafts
+ADD COLUMN content_warning TEXT,
+ADD COLUMN sensitive BOOLEAN NOT NULL DEFAULT false;
+
+-- show_sensitive is the user's preference for seeing sensitive chirps
+-- expanded. Moderators are appointed directly in the database.
+ALTER TABLE users
+ADD COLUMN show_sensitive BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN is_moderator BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN is_moderator,
+DROP COLUMN show_sensitive;
+
+ALTER TABLE chirp_drafts
+DROP COLUMN sensitive,
+DROP COLUMN content_warning;
+
+ALTER TABLE chirps
+DROP COLUMN sensitive_by_moderator,
+DROP COLUMN sensitive,
+DROP COLUMN content_warning;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Every login starts a new family of refresh tokens, and each rotation adds
+-- the replacement to the same family. replaced_by is set on a token once it
+-- has been rotated, so seeing it presented again means it was stolen.
+ALTER TABLE refresh_tokens
+ADD COLUMN family_id UUID NOT NULL DEFAULT gen_random_uuid(),
+ADD COLUMN replaced_by TEXT;
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens(family_id);
+
+-- +goose Down
+DROP INDEX refresh_tokens_family_id_idx;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN replaced_by,
+DROP COLUMN family_id;
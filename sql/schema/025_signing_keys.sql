@@ -0,0 +1,17 @@
+-- +goose Up
+-- Keys access tokens are signed with. They're kept here, rather than on each
+-- server, so every instance signs and verifies with the same set and a
+-- restart doesn't log everyone out. A key is published as soon as it's
+-- created but only signs from not_before on; retires_at is set when it's
+-- replaced, late enough that the tokens it already signed have expired.
+CREATE TABLE signing_keys (
+    kid TEXT PRIMARY KEY,
+    algorithm TEXT NOT NULL CHECK (algorithm IN ('EdDSA', 'RS256')),
+    private_key BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    not_before TIMESTAMP NOT NULL,
+    retires_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE signing_keys;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Private keys are now encrypted with SIGNING_KEY_ENCRYPTION_KEY before
+-- they're stored, so a copy of the database can't be used to mint tokens.
+-- The plaintext keys are dropped rather than encrypted here; a new key is
+-- made on the next start, and tokens signed with the old ones stop working.
+DELETE FROM signing_keys;
+ALTER TABLE signing_keys RENAME COLUMN private_key TO encrypted_private_key;
+
+-- +goose Down
+DELETE FROM signing_keys;
+ALTER TABLE signing_keys RENAME COLUMN encrypted_private_key TO private_key;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Second factor attempts are counted per user as well as per MFA token, since
+-- anyone with the password can get as many tokens as they like. A user who
+-- reaches the limit is locked out of 2FA until mfa_locked_until; a correct
+-- code resets the count.
+ALTER TABLE users
+ADD COLUMN mfa_attempts INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN mfa_locked_until TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN mfa_locked_until,
+DROP COLUMN mfa_attempts;
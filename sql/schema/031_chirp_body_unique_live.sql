@@ -0,0 +1,9 @@
+-- +goose Up
+-- Only chirps outside the trash need unique bodies, so trashing a chirp frees
+-- its text to be posted again. Restoring it then conflicts with the new one.
+ALTER TABLE chirps DROP CONSTRAINT chirps_body_key;
+CREATE UNIQUE INDEX chirps_body_live_idx ON chirps(body) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX chirps_body_live_idx;
+ALTER TABLE chirps ADD CONSTRAINT chirps_body_key UNIQUE (body);
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Rechirps show up in the sharer's profile and their followers' timelines as
+-- entries of their own, so each needs an ID for feed cursors to point at.
+ALTER TABLE rechirps
+ADD COLUMN id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE;
+
+-- +goose Down
+ALTER TABLE rechirps
+DROP COLUMN id;
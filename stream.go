@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/stream"
+	"github.com/RobertGolawski/Chirpy/internal/ws"
+	"github.com/google/uuid"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+type deletedChirpEvent struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+// publishChirp pushes a new or edited chirp to SSE subscribers and to
+// websocket clients following its author or any of its hashtags, as long as
+// they're allowed to see it. The payload is built without a viewer, so
+// viewer-specific fields like liked_by_me are left out.
+func (cfg *apiConfig) publishChirp(ctx context.Context, event string, c database.Chirp) {
+	audience, err := cfg.chirpAudience(ctx, c)
+	if err != nil {
+		log.Printf("Error finding audience for stream: %v", err)
+		return
+	}
+	chirps, err := cfg.buildChirpResponses(ctx, []database.Chirp{c}, uuid.NullUUID{})
+	if err != nil {
+		log.Printf("Error building chirp for stream: %v", err)
+		return
+	}
+	data, err := json.Marshal(chirps[0])
+	if err != nil {
+		log.Printf("Error marshalling chirp for stream: %v", err)
+		return
+	}
+	cfg.publishToChannels(event, c, chirps[0].Hashtags, audience, data)
+}
+
+// publishChirpDeleted tells the people who could see c that it's gone.
+// Callers look up the audience before deleting, while c's mentions are still
+// there to find.
+func (cfg *apiConfig) publishChirpDeleted(c database.Chirp, audience map[uuid.UUID]bool) {
+	data, _ := json.Marshal(deletedChirpEvent{
+		ID:     c.ID.String(),
+		UserID: c.UserID.UUID.String(),
+	})
+	cfg.publishToChannels(stream.EventChirpDeleted, c, nil, audience, data)
+}
+
+func (cfg *apiConfig) publishToChannels(event string, c database.Chirp, hashtags []string, audience map[uuid.UUID]bool, data []byte) {
+	cfg.broadcaster.PublishTo(event, c.ID, c.UserID.UUID, audience, data)
+
+	publish := cfg.hub.Publish
+	if audience != nil {
+		publish = func(channel, event string, data []byte) {
+			cfg.hub.PublishTo(channel, event, data, audience)
+		}
+	}
+	if c.UserID.Valid {
+		publish(ws.UserChannel(c.UserID.UUID), event, data)
+	}
+	for _, tag := range hashtags {
+		publish(ws.HashtagChannel(tag), event, data)
+	}
+}
+
+// streamChirps sends chirp create, edit and delete events as server-sent events.
+// ?author_id= limits the stream to one author and ?timeline=true to the
+// people the caller follows. Clients that reconnect with Last-Event-ID get
+// whatever they missed, as long as it's still in the broadcaster's history.
+// Without a bearer token the stream only carries public chirps. With one, the
+// stream is closed when the token expires and the client has to reconnect
+// with a fresh one.
+func (cfg *apiConfig) streamChirps(w http.ResponseWriter, r *http.Request) {
+	var filter func(stream.Event) bool
+	viewer, expiresAt := cfg.streamViewer(r)
+
+	if a := r.URL.Query().Get("author_id"); a != "" {
+		authorID, err := uuid.Parse(a)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			resp := map[string]string{"error": "Invalid author_id"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+		filter = func(e stream.Event) bool { return e.AuthorID == authorID }
+	}
+
+	if r.URL.Query().Get("timeline") == "true" {
+		w.Header().Set("Content-Type", "application/json")
+		bearerToken, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			log.Printf("Error getting bearer token: %v", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+
+		userID, exp, err := auth.ValidateJWTWithExpiry(bearerToken, cfg.keys)
+		if err != nil {
+			log.Printf("Error with validation of the JWT in stream: %v", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+
+		viewer = uuid.NullUUID{UUID: userID, Valid: true}
+		expiresAt = exp
+
+		fs, err := cfg.queries.GetFollowing(r.Context(), userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			resp := map[string]string{"error": "Something went wrong during retrieval"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+
+		// The follow set is taken once when the stream opens, the same way
+		// GET /api/timeline would see it at that moment.
+		following := map[uuid.UUID]bool{userID: true}
+		for _, f := range fs {
+			following[f.ID] = true
+		}
+		authorFilter := filter
+		filter = func(e stream.Event) bool {
+			if authorFilter != nil && !authorFilter(e) {
+				return false
+			}
+			return following[e.AuthorID]
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Streaming is not supported"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	var lastEventID uint64
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		lastEventID, _ = strconv.ParseUint(id, 10, 64)
+	}
+
+	sub, backlog := cfg.broadcaster.Subscribe(lastEventID, viewer.UUID, filter)
+	defer cfg.broadcaster.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range backlog {
+		writeStreamEvent(w, e)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	var expired <-chan time.Time
+	if viewer.Valid {
+		expiry := time.NewTimer(time.Until(expiresAt))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-expired:
+			// The viewer can no longer prove who they are, so they stop
+			// getting events meant for them. The client reconnects with a
+			// new token, or without one, and resumes from its Last-Event-ID.
+			return
+		case e, ok := <-sub.Events:
+			if !ok {
+				// Evicted for falling behind. Closing the response makes the
+				// client reconnect and resume from its Last-Event-ID.
+				return
+			}
+			writeStreamEvent(w, e)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// streamViewer is viewerID for the stream, which also needs to know when the
+// caller's token runs out.
+func (cfg *apiConfig) streamViewer(r *http.Request) (uuid.NullUUID, time.Time) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}, time.Time{}
+	}
+	userID, expiresAt, err := auth.ValidateJWTWithExpiry(bearerToken, cfg.keys)
+	if err != nil {
+		return uuid.NullUUID{}, time.Time{}
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}, expiresAt
+}
+
+func writeStreamEvent(w http.ResponseWriter, e stream.Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxThreadDepth = 50
+
+// threadNode is a chirp in a thread. Deleted chirps that still have replies
+// show up as tombstones: just the ID and deleted set, no chirp.
+type threadNode struct {
+	ID      string         `json:"id"`
+	Deleted bool           `json:"deleted,omitempty"`
+	Chirp   *chirpResponse `json:"chirp,omitempty"`
+	Replies []*threadNode  `json:"replies,omitempty"`
+}
+
+type threadResponse struct {
+	Ancestors []*threadNode `json:"ancestors"`
+	Chirp     chirpResponse `json:"chirp"`
+	Replies   []*threadNode `json:"replies"`
+}
+
+func (cfg *apiConfig) getChirpThread(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	parsedPath, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	viewer := cfg.viewerID(r)
+	c, err := cfg.getVisibleChirp(r.Context(), parsedPath, viewer)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	// Walk up the reply chain. Tombstones remember their own parent, so a
+	// deleted chirp in the middle of the chain doesn't cut it short. A parent
+	// the viewer can't see ends the chain there, as if it were the root.
+	var ancestorIDs []uuid.UUID
+	chirpRows := []database.Chirp{c}
+	parent := c.InReplyTo
+	for parent.Valid && len(ancestorIDs) < maxThreadDepth {
+		p, err := cfg.getVisibleChirp(r.Context(), parent.UUID, viewer)
+		if err == nil {
+			ancestorIDs = append(ancestorIDs, parent.UUID)
+			chirpRows = append(chirpRows, p)
+			parent = p.InReplyTo
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error fetching thread ancestor: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			resp := map[string]string{"error": "Something went wrong during retrieval"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+
+		t, err := cfg.queries.GetChirpTombstone(r.Context(), parent.UUID)
+		if err != nil {
+			break
+		}
+		ancestorIDs = append(ancestorIDs, parent.UUID)
+		parent = t.InReplyTo
+	}
+
+	descendants, err := cfg.queries.GetChirpDescendants(r.Context(), database.GetChirpDescendantsParams{
+		ChirpID:  c.ID,
+		ViewerID: viewer,
+		MaxDepth: maxThreadDepth,
+	})
+	if err != nil {
+		log.Printf("Error fetching thread descendants: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	descendantIDs := make([]uuid.UUID, 0, len(descendants))
+	for _, d := range descendants {
+		descendantIDs = append(descendantIDs, d.ID)
+	}
+	if len(descendantIDs) > 0 {
+		ds, err := cfg.queries.GetChirpsByIDs(r.Context(), database.GetChirpsByIDsParams{
+			ChirpIds: descendantIDs,
+			ViewerID: viewer,
+		})
+		if err != nil {
+			log.Printf("Error fetching thread replies: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			resp := map[string]string{"error": "Something went wrong during retrieval"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+		chirpRows = append(chirpRows, ds...)
+	}
+
+	chirps, err := cfg.buildChirpResponses(r.Context(), chirpRows, viewer)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	byID := map[uuid.UUID]*chirpResponse{}
+	for i := range chirps {
+		byID[chirpRows[i].ID] = &chirps[i]
+	}
+
+	newNode := func(id uuid.UUID) *threadNode {
+		n := &threadNode{ID: id.String()}
+		if resp, ok := byID[id]; ok {
+			n.Chirp = resp
+		} else {
+			n.Deleted = true
+		}
+		return n
+	}
+
+	resp := threadResponse{
+		Ancestors: []*threadNode{},
+		Chirp:     chirps[0],
+		Replies:   []*threadNode{},
+	}
+	for i := len(ancestorIDs) - 1; i >= 0; i-- {
+		resp.Ancestors = append(resp.Ancestors, newNode(ancestorIDs[i]))
+	}
+
+	// Descendants come back ordered by depth, so a reply's parent is always
+	// placed before the reply itself.
+	nodes := map[uuid.UUID]*threadNode{}
+	for _, d := range descendants {
+		n := newNode(d.ID)
+		nodes[d.ID] = n
+		if d.InReplyTo.UUID == c.ID {
+			resp.Replies = append(resp.Replies, n)
+		} else if p, ok := nodes[d.InReplyTo.UUID]; ok {
+			p.Replies = append(p.Replies, n)
+		}
+	}
+
+	jsonResp, err := json.Marshal(resp)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during response generation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+const (
+	// chirpTrashRetention is how long deleted chirps can be restored before
+	// the purger removes them for good.
+	chirpTrashRetention = 30 * 24 * time.Hour
+	trashPurgeInterval  = time.Hour
+	trashPurgeBatchSize = 500
+)
+
+type trashedChirpResponse struct {
+	chirpResponse
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+func (cfg *apiConfig) getTrash(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in trash: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	cs, err := cfg.queries.GetUserTrash(r.Context(), database.GetUserTrashParams{
+		UserID:           uuid.NullUUID{UUID: userID, Valid: true},
+		RetentionSeconds: chirpTrashRetention.Seconds(),
+	})
+	if err != nil {
+		log.Printf("Error fetching trash: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	chirps, err := cfg.buildChirpResponses(r.Context(), cs, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during retrieval"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	trash := []trashedChirpResponse{}
+	for i, c := range cs {
+		trash = append(trash, trashedChirpResponse{
+			chirpResponse: chirps[i],
+			DeletedAt:     c.DeletedAt.Time,
+			PurgeAt:       c.DeletedAt.Time.Add(chirpTrashRetention),
+		})
+	}
+
+	jsonResp, err := json.Marshal(trash)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during response generation"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
+
+func (cfg *apiConfig) restoreChirpByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.PathValue("id")
+	parsedPath, err := uuid.Parse(path)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during parsing"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in restore: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	c, err := cfg.queries.GetTrashedChirp(r.Context(), parsedPath)
+	if err != nil {
+		w.WriteHeader(404)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if userID != c.UserID.UUID {
+		w.WriteHeader(http.StatusForbidden)
+		resp := map[string]string{"error": "Forbidden"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	restored, err := cfg.restoreChirp(r.Context(), c)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusGone)
+		resp := map[string]string{"error": "This chirp is past the restore window"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if isChirpBodyConflict(err) {
+		w.WriteHeader(http.StatusConflict)
+		resp := map[string]string{"error": "A chirp with that body already exists"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if err != nil {
+		log.Printf("Error restoring chirp: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during restore"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	chirps, err := cfg.buildChirpResponses(r.Context(), []database.Chirp{restored}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during restore"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	jsonResp, err := json.Marshal(chirps[0])
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during marshalling"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
+
+// restoreChirp takes a chirp back out of the trash. Its tombstone, if it had
+// one, goes away since the chirp itself is visible again. If its parent is
+// still in the trash, the parent gets a tombstone instead so the restored
+// reply still hangs off the thread. sql.ErrNoRows means the chirp is past
+// the retention window, and a body conflict that the same text has been
+// posted again since it was trashed.
+func (cfg *apiConfig) restoreChirp(ctx context.Context, c database.Chirp) (database.Chirp, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	restored, err := qtx.RestoreChirp(ctx, database.RestoreChirpParams{
+		ID:               c.ID,
+		RetentionSeconds: chirpTrashRetention.Seconds(),
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := qtx.DeleteChirpTombstone(ctx, c.ID); err != nil {
+		return database.Chirp{}, err
+	}
+
+	if c.InReplyTo.Valid {
+		parent, err := qtx.GetTrashedChirp(ctx, c.InReplyTo.UUID)
+		if err == nil {
+			err = qtx.CreateChirpTombstone(ctx, database.CreateChirpTombstoneParams{
+				ID:        parent.ID,
+				CreatedAt: parent.CreatedAt,
+				InReplyTo: parent.InReplyTo,
+			})
+			if err != nil {
+				return database.Chirp{}, err
+			}
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return database.Chirp{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.Chirp{}, err
+	}
+	cfg.publishChirp(ctx, stream.EventChirpRestored, restored)
+	return restored, nil
+}
+
+// purgeTrash hard-deletes chirps that have been in the trash longer than the
+// retention window, in batches so one run doesn't hold a huge lock. Likes,
+// rechirps, hashtag links and revisions go with them through their foreign
+// keys.
+func (cfg *apiConfig) purgeTrash(ctx context.Context) error {
+	for {
+		n, err := cfg.queries.PurgeExpiredChirps(ctx, database.PurgeExpiredChirpsParams{
+			RetentionSeconds: chirpTrashRetention.Seconds(),
+			BatchSize:        trashPurgeBatchSize,
+		})
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			log.Printf("Purged %d chirps from the trash", n)
+		}
+		if n < trashPurgeBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,653 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RobertGolawski/Chirpy/internal/auth"
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/totp"
+)
+
+// Two-factor authentication with an authenticator app. Once it's on, a
+// correct password only gets a short-lived MFA token from /api/login; the
+// access and refresh tokens come from /api/login/2fa with that token and a
+// current code or one of the recovery codes handed out when 2FA was turned
+// on. TOTP secrets are encrypted with TOTP_ENCRYPTION_KEY before they're
+// stored, and without that key 2FA can't be used at all.
+
+const (
+	totpIssuer      = "Chirpy"
+	mfaChallengeTTL = 5 * time.Minute
+	maxMFAAttempts  = 5
+	// maxUserMFAAttempts is how many codes a user can try, across every MFA
+	// token, before 2FA is locked for mfaLockout. A correct code starts the
+	// count again.
+	maxUserMFAAttempts = 10
+	mfaLockout         = 15 * time.Minute
+	recoveryCodeCount  = 10
+	recoveryCodeBytes  = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCodes returns fresh codes formatted for people to copy,
+// like abcd-efgh-ijkl-mnop.
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		b := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		s := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		codes = append(codes, s[0:4]+"-"+s[4:8]+"-"+s[8:12]+"-"+s[12:16])
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode ignores case and dashes, so codes typed back in any
+// reasonable way still match.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return auth.HashToken(code)
+}
+
+// replaceRecoveryCodes throws away any codes the user had and stores new
+// ones, returning them so they can be shown once.
+func replaceRecoveryCodes(ctx context.Context, qtx *database.Queries, u database.User) ([]string, error) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := qtx.DeleteRecoveryCodes(ctx, u.ID); err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		err := qtx.CreateRecoveryCode(ctx, database.CreateRecoveryCodeParams{
+			UserID:   u.ID,
+			CodeHash: hashRecoveryCode(code),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// openTOTPSecret decrypts u's TOTP secret. The user's ID is bound into the
+// ciphertext, so a secret copied onto another user's row won't open.
+func (cfg *apiConfig) openTOTPSecret(u database.User) (string, error) {
+	secret, err := cfg.secrets.Open(u.TotpSecret, u.ID[:])
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// checkTOTPCode reports whether code is a current code for u that hasn't
+// been used before.
+func (cfg *apiConfig) checkTOTPCode(ctx context.Context, u database.User, code string) (bool, error) {
+	secret, err := cfg.openTOTPSecret(u)
+	if err != nil {
+		return false, err
+	}
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	n, err := cfg.queries.UseTOTPStep(ctx, database.UseTOTPStepParams{
+		Step: step,
+		ID:   u.ID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// checkSecondFactor accepts either a TOTP code or an unused recovery code,
+// using up the recovery code if that's what matched.
+func (cfg *apiConfig) checkSecondFactor(ctx context.Context, u database.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		return cfg.checkTOTPCode(ctx, u, code)
+	}
+	if recoveryCode == "" {
+		return false, nil
+	}
+	n, err := cfg.queries.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+		UserID:   u.ID,
+		CodeHash: hashRecoveryCode(recoveryCode),
+	})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// claimMFAAttempt counts an attempt at u's second factor before it's
+// checked, so parallel requests can't get past the limit, and reports false
+// if u is locked out.
+func (cfg *apiConfig) claimMFAAttempt(ctx context.Context, u database.User) (bool, error) {
+	n, err := cfg.queries.ClaimUserMFAAttempt(ctx, database.ClaimUserMFAAttemptParams{
+		MaxAttempts:    maxUserMFAAttempts,
+		LockoutSeconds: mfaLockout.Seconds(),
+		ID:             u.ID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// createMFAChallenge starts the second step of a login for u and
+// returns the token the client finishes it with.
+func (cfg *apiConfig) createMFAChallenge(ctx context.Context, u database.User) (string, error) {
+	if err := cfg.queries.DeleteExpiredMFAChallenges(ctx); err != nil {
+		return "", err
+	}
+	token, err := auth.MakeOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	err = cfg.queries.CreateMFAChallenge(ctx, database.CreateMFAChallengeParams{
+		TokenHash:  auth.HashToken(token),
+		UserID:     u.ID,
+		TtlSeconds: mfaChallengeTTL.Seconds(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// setupTwoFactor generates a new TOTP secret for the caller. It isn't used
+// for logins until confirmTwoFactor sees a code from it, and running setup
+// again before then replaces it.
+func (cfg *apiConfig) setupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in 2fa setup: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if cfg.secrets == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		resp := map[string]string{"error": "Two-factor authentication isn't available"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	u, err := cfg.queries.GetUser(r.Context(), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Printf("Error generating TOTP secret: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during 2fa setup"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	sealed, err := cfg.secrets.Seal([]byte(secret), u.ID[:])
+	if err != nil {
+		log.Printf("Error encrypting TOTP secret: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during 2fa setup"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	n, err := cfg.queries.StartTOTPSetup(r.Context(), database.StartTOTPSetupParams{
+		ID:         u.ID,
+		TotpSecret: sealed,
+	})
+	if err != nil {
+		log.Printf("Error storing TOTP secret: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during 2fa setup"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if n == 0 {
+		w.WriteHeader(http.StatusConflict)
+		resp := map[string]string{"error": "Two-factor authentication is already on"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	resp := struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(secret, totpIssuer, u.Email),
+	}
+	jsonResp, err := json.Marshal(resp)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during marshalling"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
+
+// confirmTwoFactor turns 2FA on once the caller proves their app has the
+// secret, and hands out the recovery codes. They're only ever shown here.
+func (cfg *apiConfig) confirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code string `json:"code"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in 2fa confirm: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		log.Printf("Error decoding JSON: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with parsing JSON"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if cfg.secrets == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		resp := map[string]string{"error": "Two-factor authentication isn't available"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	u, err := cfg.queries.GetUser(r.Context(), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if u.TotpEnabledAt.Valid {
+		w.WriteHeader(http.StatusConflict)
+		resp := map[string]string{"error": "Two-factor authentication is already on"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if u.TotpSecret == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Two-factor authentication hasn't been set up"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	secret, err := cfg.openTOTPSecret(u)
+	if err != nil {
+		log.Printf("Error decrypting TOTP secret: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during 2fa confirm"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	step, ok := totp.Validate(secret, params.Code, time.Now())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Invalid code"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	codes, err := cfg.enableTwoFactor(r.Context(), u, step)
+	if err != nil {
+		log.Printf("Error enabling 2fa: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during 2fa confirm"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if codes == nil {
+		w.WriteHeader(http.StatusConflict)
+		resp := map[string]string{"error": "Two-factor authentication is already on"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	resp := struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		RecoveryCodes: codes,
+	}
+	jsonResp, err := json.Marshal(resp)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during marshalling"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResp)
+}
+
+// enableTwoFactor switches 2FA on and issues recovery codes in one go. It
+// returns no codes if a concurrent confirm got there first.
+func (cfg *apiConfig) enableTwoFactor(ctx context.Context, u database.User, step int64) ([]string, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	n, err := qtx.EnableTOTP(ctx, database.EnableTOTPParams{
+		Step: step,
+		ID:   u.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	codes, err := replaceRecoveryCodes(ctx, qtx, u)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// disableTwoFactor turns 2FA off. It takes a code or recovery code as well
+// as the access token, so a stolen access token alone can't remove it.
+func (cfg *apiConfig) disableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with getting bearer token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
+	if err != nil {
+		log.Printf("Error with validation of the JWT in 2fa disable: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Something went wrong with validating the jwt"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		log.Printf("Error decoding JSON: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with parsing JSON"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if cfg.secrets == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		resp := map[string]string{"error": "Two-factor authentication isn't available"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	u, err := cfg.queries.GetUser(r.Context(), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if !u.TotpEnabledAt.Valid {
+		w.WriteHeader(http.StatusConflict)
+		resp := map[string]string{"error": "Two-factor authentication is off"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	allowed, err := cfg.claimMFAAttempt(r.Context(), u)
+	if err != nil {
+		log.Printf("Error counting MFA attempt: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during 2fa disable"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if !allowed {
+		w.WriteHeader(http.StatusTooManyRequests)
+		resp := map[string]string{"error": "Too many wrong codes, try again later"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	ok, err := cfg.checkSecondFactor(r.Context(), u, params.Code, params.RecoveryCode)
+	if err != nil {
+		log.Printf("Error checking second factor: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during 2fa disable"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Invalid code"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if err := cfg.queries.DisableTOTP(r.Context(), u.ID); err != nil {
+		log.Printf("Error disabling 2fa: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during 2fa disable"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if err := cfg.queries.DeleteRecoveryCodes(r.Context(), u.ID); err != nil {
+		log.Printf("Error deleting recovery codes: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during 2fa disable"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// logInSecondFactor finishes a login that logInRequest answered with an MFA
+// token. Each token allows a few wrong codes before the password has to be
+// entered again, and each user a few more across tokens before they're
+// locked out for a while.
+func (cfg *apiConfig) logInSecondFactor(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		log.Printf("Error decoding JSON: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Something went wrong with parsing JSON"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	if cfg.secrets == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		resp := map[string]string{"error": "Two-factor authentication isn't available"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	// The attempt is counted before the code is checked, so parallel
+	// requests can't get more than maxMFAAttempts guesses out of one token.
+	tokenHash := auth.HashToken(params.MFAToken)
+	userID, err := cfg.queries.ClaimMFAChallengeAttempt(r.Context(), database.ClaimMFAChallengeAttemptParams{
+		TokenHash:   tokenHash,
+		MaxAttempts: maxMFAAttempts,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Invalid or expired MFA token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	u, err := cfg.queries.GetUser(r.Context(), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during querying"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	// 2FA was turned off since the password was checked.
+	if !u.TotpEnabledAt.Valid {
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Invalid or expired MFA token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	allowed, err := cfg.claimMFAAttempt(r.Context(), u)
+	if err != nil {
+		log.Printf("Error counting MFA attempt: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during login"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if !allowed {
+		w.WriteHeader(http.StatusTooManyRequests)
+		resp := map[string]string{"error": "Too many wrong codes, try again later"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	ok, err := cfg.checkSecondFactor(r.Context(), u, params.Code, params.RecoveryCode)
+	if err != nil {
+		log.Printf("Error checking second factor: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong during login"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Invalid code"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+	if err := cfg.queries.ResetUserMFAAttempts(r.Context(), u.ID); err != nil {
+		log.Printf("Error resetting MFA attempts: %v", err)
+	}
+
+	// Only one request gets to use the token.
+	n, err := cfg.queries.DeleteMFAChallenge(r.Context(), tokenHash)
+	if err != nil || n == 0 {
+		w.WriteHeader(http.StatusUnauthorized)
+		resp := map[string]string{"error": "Invalid or expired MFA token"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	cfg.logIn(w, r, u)
+}
@@ -1,32 +1,82 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/RobertGolawski/Chirpy/internal/auth"
 	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/RobertGolawski/Chirpy/internal/mention"
+	"github.com/RobertGolawski/Chirpy/internal/notifications"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type userResponse struct {
-	ID           string    `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Email        string    `json:"email"`
-	Token        string    `json:"token"`
-	RefreshToken string    `json:"refresh_token"`
-	IsRed        bool      `json:"is_chirpy_red"`
+	ID            string    `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Email         string    `json:"email"`
+	Handle        string    `json:"handle,omitempty"`
+	Token         string    `json:"token"`
+	RefreshToken  string    `json:"refresh_token"`
+	IsRed         bool      `json:"is_chirpy_red"`
+	ShowSensitive bool      `json:"show_sensitive"`
 }
 
 type parameters struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Handle   string `json:"handle"`
 	// Expiration time.Duration `json:"expires_in_seconds"`
 }
 
+// userUpdate is the body of PUT /api/users. Anything left out keeps its
+// current value, so a client can change one setting without resending the
+// user's credentials.
+type userUpdate struct {
+	Email         *string `json:"email"`
+	Password      *string `json:"password"`
+	Handle        string  `json:"handle"`
+	ShowSensitive *bool   `json:"show_sensitive"`
+}
+
+// validate returns what's wrong with the update for the client, or "" if
+// nothing is. Blanking the email or password isn't allowed.
+func (u userUpdate) validate() string {
+	if u.Email != nil && *u.Email == "" {
+		return "Email can't be empty"
+	}
+	if u.Password != nil && *u.Password == "" {
+		return "Password can't be empty"
+	}
+	if u.Handle != "" && !mention.ValidHandle(u.Handle) {
+		return "Handles must be 1-15 letters, digits or underscores"
+	}
+	return ""
+}
+
+type profileResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Handle    string    `json:"handle"`
+	IsRed     bool      `json:"is_chirpy_red"`
+}
+
+var errHandleTaken = errors.New("handle already taken")
+
+// isHandleConflict reports whether err is the unique index on LOWER(handle)
+// rejecting a handle someone else already has.
+func isHandleConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "users_handle_lower_idx"
+}
+
 func (cfg *apiConfig) createUserRequest(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
@@ -41,7 +91,25 @@ func (cfg *apiConfig) createUserRequest(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	u, err := cfg.queries.CreateUser(r.Context(), params.Email)
+	if params.Handle != "" && !mention.ValidHandle(params.Handle) {
+		w.WriteHeader(http.StatusBadRequest)
+		resp := map[string]string{"error": "Handles must be 1-15 letters, digits or underscores"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
+
+	u, err := cfg.queries.CreateUser(r.Context(), database.CreateUserParams{
+		Email:  params.Email,
+		Handle: sql.NullString{String: params.Handle, Valid: params.Handle != ""},
+	})
+	if isHandleConflict(err) {
+		w.WriteHeader(http.StatusConflict)
+		resp := map[string]string{"error": "Handle already taken"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
 	if err != nil {
 		log.Printf("Error creating the user: here %s", err)
 		w.WriteHeader(http.StatusBadRequest)
@@ -72,7 +140,7 @@ func (cfg *apiConfig) createUserRequest(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	tokenString, err := auth.MakeJWT(u.ID, cfg.secret, 3600*time.Second)
+	tokenString, err := auth.MakeJWT(u.ID, cfg.keys, accessTokenTTL)
 	if err != nil {
 		log.Printf("Error making the JWT in user create: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -82,7 +150,7 @@ func (cfg *apiConfig) createUserRequest(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	checkID, err := auth.ValidateJWT(tokenString, cfg.secret)
+	checkID, err := auth.ValidateJWT(tokenString, cfg.keys)
 	if err != nil {
 		log.Printf("Error with validation of the JWT in user create: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -111,10 +179,13 @@ func (cfg *apiConfig) createUserRequest(w http.ResponseWriter, r *http.Request)
 	}
 
 	nullID := uuid.NullUUID{UUID: checkID, Valid: true}
+	client := requestClient(r)
 	err = cfg.queries.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
 		Token:     refreshToken,
 		UserID:    nullID,
-		ExpiresAt: time.Now().Add(60 * 24 * time.Hour),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+		UserAgent: client.userAgent,
+		IpAddress: client.ipAddress,
 	})
 	if err != nil {
 		log.Printf("Error inserting the refresh token into db: %v", err)
@@ -127,13 +198,15 @@ func (cfg *apiConfig) createUserRequest(w http.ResponseWriter, r *http.Request)
 
 	w.WriteHeader(http.StatusCreated)
 	resp := userResponse{
-		ID:           u.ID.String(),
-		CreatedAt:    u.CreatedAt,
-		UpdatedAt:    u.UpdatedAt,
-		Email:        u.Email,
-		Token:        tokenString,
-		RefreshToken: refreshToken,
-		IsRed:        u.IsChirpyRed,
+		ID:            u.ID.String(),
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+		Email:         u.Email,
+		Handle:        u.Handle.String,
+		Token:         tokenString,
+		RefreshToken:  refreshToken,
+		IsRed:         u.IsChirpyRed,
+		ShowSensitive: u.ShowSensitive,
 	}
 	jsonResp, err := json.Marshal(resp)
 	if err != nil {
@@ -181,7 +254,36 @@ func (cfg *apiConfig) logInRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokenString, err := auth.MakeJWT(u.ID, cfg.secret, 3600*time.Second)
+	if u.TotpEnabledAt.Valid {
+		mfaToken, err := cfg.createMFAChallenge(r.Context(), u)
+		if err != nil {
+			log.Printf("Error creating MFA challenge: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			resp := map[string]string{"error": "Something went wrong during login"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+		resp := struct {
+			MFARequired bool   `json:"mfa_required"`
+			MFAToken    string `json:"mfa_token"`
+		}{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}
+		jsonResp, _ := json.Marshal(resp)
+		w.WriteHeader(http.StatusOK)
+		w.Write(jsonResp)
+		return
+	}
+
+	cfg.logIn(w, r, u)
+}
+
+// logIn hands out an access token and a new session's refresh token once
+// the user has been fully authenticated.
+func (cfg *apiConfig) logIn(w http.ResponseWriter, r *http.Request, u database.User) {
+	tokenString, err := auth.MakeJWT(u.ID, cfg.keys, accessTokenTTL)
 	if err != nil {
 		log.Printf("Error making the JWT in user create: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
@@ -191,7 +293,7 @@ func (cfg *apiConfig) logInRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	checkID, err := auth.ValidateJWT(tokenString, cfg.secret)
+	checkID, err := auth.ValidateJWT(tokenString, cfg.keys)
 	if err != nil {
 		log.Printf("Error with validation of the JWT in user create: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
@@ -219,10 +321,13 @@ func (cfg *apiConfig) logInRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	nullID := uuid.NullUUID{UUID: checkID, Valid: true}
+	client := requestClient(r)
 	err = cfg.queries.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
 		Token:     refreshToken,
 		UserID:    nullID,
-		ExpiresAt: time.Now().Add(60 * 24 * time.Hour),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+		UserAgent: client.userAgent,
+		IpAddress: client.ipAddress,
 	})
 	if err != nil {
 		log.Printf("Error inserting the refresh token into db: %v", err)
@@ -235,13 +340,15 @@ func (cfg *apiConfig) logInRequest(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 	resp := userResponse{
-		ID:           u.ID.String(),
-		CreatedAt:    u.CreatedAt,
-		UpdatedAt:    u.UpdatedAt,
-		Email:        u.Email,
-		Token:        tokenString,
-		RefreshToken: refreshToken,
-		IsRed:        u.IsChirpyRed,
+		ID:            u.ID.String(),
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+		Email:         u.Email,
+		Handle:        u.Handle.String,
+		Token:         tokenString,
+		RefreshToken:  refreshToken,
+		IsRed:         u.IsChirpyRed,
+		ShowSensitive: u.ShowSensitive,
 	}
 
 	jsonResp, err := json.Marshal(resp)
@@ -269,33 +376,31 @@ func (cfg *apiConfig) refreshJWT(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokenData, err := cfg.queries.GetTokenData(r.Context(), bearerToken)
-	if err != nil {
-		log.Printf("Error getting bearer token: %v", err)
+	tokenData, refreshToken, err := cfg.rotateRefreshToken(r.Context(), bearerToken, requestClient(r))
+	if errors.Is(err, errRefreshTokenReused) {
 		w.WriteHeader(http.StatusUnauthorized)
-		resp := map[string]string{"error": "Invalid bearer token"}
+		resp := map[string]string{"error": "reused bearer token"}
 		jsonResp, _ := json.Marshal(resp)
 		w.Write(jsonResp)
 		return
 	}
-
-	if tokenData.ExpiresAt.Before(time.Now()) {
+	if errors.Is(err, errRefreshTokenInvalid) {
 		w.WriteHeader(http.StatusUnauthorized)
-		resp := map[string]string{"error": "expired bearer token"}
+		resp := map[string]string{"error": "Invalid bearer token"}
 		jsonResp, _ := json.Marshal(resp)
 		w.Write(jsonResp)
 		return
 	}
-
-	if tokenData.RevokedAt.Valid {
-		w.WriteHeader(http.StatusUnauthorized)
-		resp := map[string]string{"error": "revoked bearer token"}
+	if err != nil {
+		log.Printf("Error rotating refresh token: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := map[string]string{"error": "Something went wrong refresh token"}
 		jsonResp, _ := json.Marshal(resp)
 		w.Write(jsonResp)
 		return
 	}
 
-	tokenString, err := auth.MakeJWT(tokenData.UserID.UUID, cfg.secret, 3600*time.Second)
+	tokenString, err := auth.MakeJWT(tokenData.UserID.UUID, cfg.keys, accessTokenTTL)
 	if err != nil {
 		log.Printf("Error making the JWT in user create: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
@@ -305,7 +410,7 @@ func (cfg *apiConfig) refreshJWT(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	checkID, err := auth.ValidateJWT(tokenString, cfg.secret)
+	checkID, err := auth.ValidateJWT(tokenString, cfg.keys)
 	if err != nil {
 		log.Printf("Error with validation of the JWT in user create: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
@@ -325,9 +430,11 @@ func (cfg *apiConfig) refreshJWT(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 	resp := struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}{
-		Token: tokenString,
+		Token:        tokenString,
+		RefreshToken: refreshToken,
 	}
 
 	jsonResp, err := json.Marshal(resp)
@@ -404,7 +511,7 @@ func (cfg *apiConfig) updateUserDetails(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(bearerToken, cfg.secret)
+	userID, err := auth.ValidateJWT(bearerToken, cfg.keys)
 	if err != nil {
 		log.Printf("Error with validation of the JWT in user update: %v", err)
 		w.WriteHeader(http.StatusUnauthorized)
@@ -415,7 +522,7 @@ func (cfg *apiConfig) updateUserDetails(w http.ResponseWriter, r *http.Request)
 	}
 
 	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
+	params := userUpdate{}
 	if err := decoder.Decode(&params); err != nil {
 		log.Printf("Error decoding JSON: %s", err)
 		w.WriteHeader(http.StatusBadRequest)
@@ -425,68 +532,158 @@ func (cfg *apiConfig) updateUserDetails(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = cfg.queries.UpdateEmail(r.Context(), database.UpdateEmailParams{Email: params.Email, ID: userID})
-	if err != nil {
-		log.Printf("Error updating email: %s", err)
+	if problem := params.validate(); problem != "" {
 		w.WriteHeader(http.StatusBadRequest)
-		resp := map[string]string{"error": "Something went wrong during email update"}
+		resp := map[string]string{"error": problem}
 		jsonResp, _ := json.Marshal(resp)
 		w.Write(jsonResp)
 		return
 	}
 
-	hp, err := auth.HashPassword(params.Password)
+	var hashedPassword *string
+	if params.Password != nil {
+		hp, err := auth.HashPassword(*params.Password)
+		if err != nil {
+			log.Printf("Error creating the hash: %s", err)
+			w.WriteHeader(http.StatusBadRequest)
+			resp := map[string]string{"error": "Something went wrong with hashing"}
+			jsonResp, _ := json.Marshal(resp)
+			w.Write(jsonResp)
+			return
+		}
+		hashedPassword = &hp
+	}
+
+	u, err := cfg.applyUserUpdate(r.Context(), userID, params, hashedPassword)
+	if errors.Is(err, errHandleTaken) {
+		w.WriteHeader(http.StatusConflict)
+		resp := map[string]string{"error": "Handle already taken"}
+		jsonResp, _ := json.Marshal(resp)
+		w.Write(jsonResp)
+		return
+	}
 	if err != nil {
-		log.Printf("Error creating the hash: %s", err)
+		log.Printf("Error updating the user: %s", err)
 		w.WriteHeader(http.StatusBadRequest)
-		resp := map[string]string{"error": "Something went wrong with hashing"}
+		resp := map[string]string{"error": "Something went wrong during user update"}
 		jsonResp, _ := json.Marshal(resp)
 		w.Write(jsonResp)
 		return
 	}
 
-	if err := cfg.queries.UpdatePassword(r.Context(), database.UpdatePasswordParams{
-		HashedPassword: hp,
-		ID:             userID,
-	}); err != nil {
-		log.Printf("Error updating the user: %s", err)
+	w.WriteHeader(http.StatusOK)
+	resp := userResponse{
+		ID:            u.ID.String(),
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+		Email:         u.Email,
+		Handle:        u.Handle.String,
+		IsRed:         u.IsChirpyRed,
+		ShowSensitive: u.ShowSensitive,
+	}
+
+	jsonResp, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Error marshalling: %s", err)
 		w.WriteHeader(http.StatusBadRequest)
-		resp := map[string]string{"error": "Something went wrong with updating password"}
+		resp := map[string]string{"error": "Something went wrong with response creation"}
 		jsonResp, _ := json.Marshal(resp)
 		w.Write(jsonResp)
 		return
 	}
+	w.Write(jsonResp)
 
-	u, err := cfg.queries.GetUserByEmail(r.Context(), params.Email)
+}
+
+// applyUserUpdate makes every change in params, with the password already
+// hashed, in one transaction, so a request that fails part way through
+// leaves the user as it was.
+func (cfg *apiConfig) applyUserUpdate(ctx context.Context, userID uuid.UUID, params userUpdate, hashedPassword *string) (database.User, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("Error fetching user by email: %s", err)
-		w.WriteHeader(http.StatusBadRequest)
-		resp := map[string]string{"error": "Something went wrong with fetching by email"}
+		return database.User{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	if params.Email != nil {
+		err := qtx.UpdateEmail(ctx, database.UpdateEmailParams{Email: *params.Email, ID: userID})
+		if err != nil {
+			return database.User{}, err
+		}
+	}
+	if hashedPassword != nil {
+		err := qtx.UpdatePassword(ctx, database.UpdatePasswordParams{
+			HashedPassword: *hashedPassword,
+			ID:             userID,
+		})
+		if err != nil {
+			return database.User{}, err
+		}
+	}
+	if params.Handle != "" {
+		err := qtx.UpdateHandle(ctx, database.UpdateHandleParams{
+			Handle: sql.NullString{String: params.Handle, Valid: true},
+			ID:     userID,
+		})
+		if isHandleConflict(err) {
+			return database.User{}, errHandleTaken
+		}
+		if err != nil {
+			return database.User{}, err
+		}
+	}
+	if params.ShowSensitive != nil {
+		err := qtx.UpdateShowSensitive(ctx, database.UpdateShowSensitiveParams{
+			ShowSensitive: *params.ShowSensitive,
+			ID:            userID,
+		})
+		if err != nil {
+			return database.User{}, err
+		}
+	}
+
+	u, err := qtx.GetUser(ctx, userID)
+	if err != nil {
+		return database.User{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return database.User{}, err
+	}
+	return u, nil
+}
+
+func (cfg *apiConfig) getUserProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	handle := mention.Normalize(r.PathValue("handle"))
+
+	u, err := cfg.queries.GetUserByHandle(r.Context(), handle)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		resp := map[string]string{"error": "User not found"}
 		jsonResp, _ := json.Marshal(resp)
 		w.Write(jsonResp)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	resp := userResponse{
+	resp := profileResponse{
 		ID:        u.ID.String(),
 		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
-		Email:     u.Email,
+		Handle:    u.Handle.String,
 		IsRed:     u.IsChirpyRed,
 	}
 
 	jsonResp, err := json.Marshal(resp)
 	if err != nil {
 		log.Printf("Error marshalling: %s", err)
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(http.StatusInternalServerError)
 		resp := map[string]string{"error": "Something went wrong with response creation"}
 		jsonResp, _ := json.Marshal(resp)
 		w.Write(jsonResp)
 		return
 	}
+	w.WriteHeader(http.StatusOK)
 	w.Write(jsonResp)
-
 }
 
 func (cfg *apiConfig) upgradeUserToRed(w http.ResponseWriter, r *http.Request) {
@@ -544,5 +741,10 @@ func (cfg *apiConfig) upgradeUserToRed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	cfg.notifier.Notify(notifications.Event{
+		Kind:   notifications.KindRedUpgrade,
+		UserID: params.Data.UserID,
+	})
+
 	w.WriteHeader(204)
 }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUserUpdateLeavesOutCredentials(t *testing.T) {
+	var u userUpdate
+	if err := json.Unmarshal([]byte(`{"show_sensitive":true}`), &u); err != nil {
+		t.Fatalf("Error decoding update: %v", err)
+	}
+	if u.Email != nil || u.Password != nil {
+		t.Fatalf("Expected email and password to be left unchanged")
+	}
+	if u.ShowSensitive == nil || !*u.ShowSensitive {
+		t.Fatalf("Expected show_sensitive to be set")
+	}
+	if problem := u.validate(); problem != "" {
+		t.Fatalf("Expected the update to be valid but got: %s", problem)
+	}
+}
+
+func TestUserUpdateRejectsEmptyCredentials(t *testing.T) {
+	for _, body := range []string{`{"email":""}`, `{"password":""}`, `{"email":"a@example.com","password":""}`} {
+		var u userUpdate
+		if err := json.Unmarshal([]byte(body), &u); err != nil {
+			t.Fatalf("Error decoding update: %v", err)
+		}
+		if u.validate() == "" {
+			t.Fatalf("Expected %s to be rejected", body)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+
+	"github.com/RobertGolawski/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Who can see a chirp. The rules themselves live in the chirp_visible_to SQL
+// function so every read query applies them the same way; chirpAudience
+// mirrors them for the live stream and websocket events.
+const (
+	visibilityPublic    = "public"
+	visibilityFollowers = "followers"
+	visibilityMentioned = "mentioned"
+)
+
+// parseVisibility checks a visibility sent by a client, defaulting to public
+// when it's left out.
+func parseVisibility(v string) (string, bool) {
+	switch v {
+	case "":
+		return visibilityPublic, true
+	case visibilityPublic, visibilityFollowers, visibilityMentioned:
+		return v, true
+	default:
+		return "", false
+	}
+}
+
+// chirpAudience returns the users allowed to see c, or nil if it's public.
+// The author and everyone mentioned are always in it, and the author's
+// followers are too for followers-only chirps.
+func (cfg *apiConfig) chirpAudience(ctx context.Context, c database.Chirp) (map[uuid.UUID]bool, error) {
+	if c.Visibility == visibilityPublic {
+		return nil, nil
+	}
+
+	audience := map[uuid.UUID]bool{}
+	if c.UserID.Valid {
+		audience[c.UserID.UUID] = true
+	}
+
+	mentions, err := cfg.queries.GetChirpMentions(ctx, []uuid.UUID{c.ID})
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mentions {
+		audience[m.UserID] = true
+	}
+
+	if c.Visibility == visibilityFollowers && c.UserID.Valid {
+		followers, err := cfg.queries.GetFollowerIDs(ctx, c.UserID.UUID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range followers {
+			audience[id] = true
+		}
+	}
+	return audience, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/RobertGolawski/Chirpy/internal/notifications"
+	"github.com/google/uuid"
+)
+
+type notificationEvent struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"`
+	ActorID string `json:"actor_id,omitempty"`
+	ChirpID string `json:"chirp_id,omitempty"`
+}
+
+// pushNotification tells the recipient's websocket connections that a
+// notification was stored. Notifications are coalesced, so clients refetch
+// GET /api/notifications for the current count and message.
+func (cfg *apiConfig) pushNotification(e notifications.Event, id uuid.UUID) {
+	n := notificationEvent{
+		ID:   id.String(),
+		Kind: string(e.Kind),
+	}
+	if e.ActorID.Valid {
+		n.ActorID = e.ActorID.UUID.String()
+	}
+	if e.ChirpID.Valid {
+		n.ChirpID = e.ChirpID.UUID.String()
+	}
+
+	data, err := json.Marshal(n)
+	if err != nil {
+		log.Printf("Error marshalling notification for websocket: %v", err)
+		return
+	}
+	cfg.hub.PublishToUser(e.UserID, "notification", data)
+}